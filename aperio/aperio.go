@@ -0,0 +1,165 @@
+// Package aperio exposes the file statistics engine behind the aperio CLI:
+// size, kind, language, line/word/char counts, and code/comment/blank lines.
+package aperio
+
+import (
+	"context"
+	"io"
+	"io/fs"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+)
+
+// FileStats holds the results for one file. When analysis fails, HasError is
+// set and ErrorText describes the failure.
+type FileStats struct {
+	Name     string
+	Path     string
+	Ext      string
+	Kind     string
+	Language string
+	// MIME and Type identify the format from its magic number, e.g.
+	// "image/png" and "PNG image"; other content is text/plain or data.
+	MIME string
+	Type string
+	// Encoding is the detected text encoding, e.g. UTF-8 or UTF-16LE; it is
+	// empty for binary files.
+	Encoding  string
+	SizeBytes int64
+	Size      string
+	Lines     int
+	// CodeLines, CommentLines and BlankLines break Lines down for files with
+	// a detected Language; they are zero otherwise.
+	CodeLines    int
+	CommentLines int
+	BlankLines   int
+	Words        int
+	// Chars counts runes, grapheme clusters or bytes after any BOM, as
+	// selected by Options.CharMode.
+	Chars int
+	// LF, CRLF and CR count each kind of line ending; EOL summarizes them
+	// as lf, crlf, cr, mixed or none. FinalNewline is also true for empty
+	// files, and TrailingSpaceLines counts lines ending in spaces or tabs.
+	// All are zero values for binary files.
+	LF                 int
+	CRLF               int
+	CR                 int
+	EOL                string
+	HasBOM             bool
+	FinalNewline       bool
+	TrailingSpaceLines int
+	// Line lengths exclude line endings and are measured in runes and in
+	// terminal cells (tabs advance to the next multiple of 8). LongestLine
+	// is the number of the first line with MaxLineLength runes.
+	MaxLineLength  int
+	MeanLineLength float64
+	P95LineLength  int
+	MaxLineWidth   int
+	MeanLineWidth  float64
+	P95LineWidth   int
+	LongestLine    int
+	// LongLines lists the lines longer than Options.MaxLineLength runes.
+	LongLines []int `json:",omitempty"`
+	// IndentStyle is tabs, spaces, mixed or none. IndentWidth is the inferred
+	// number of spaces per level (0 without space indentation), and depths
+	// count levels over non-blank lines, a tab being one level.
+	// MixedIndentLines counts lines indented with both tabs and spaces.
+	IndentStyle      string
+	IndentWidth      int
+	MixedIndentLines int
+	MaxIndentDepth   int
+	MeanIndentDepth  float64
+	// Prose is set when prose metrics were computed (Options.Prose, or .md
+	// and .txt files). ReadingEase is the Flesch Reading Ease score,
+	// GradeLevel the Flesch-Kincaid grade and ReadingMinutes the time to
+	// read the words at 238 per minute.
+	Prose          bool
+	Sentences      int
+	Paragraphs     int
+	Syllables      int
+	ReadingEase    float64
+	GradeLevel     float64
+	ReadingMinutes float64
+	ModTime        string
+	ModUnix        int64
+	HasError       bool
+	ErrorText      string
+	// Hash is the hex content digest when Options.Hash is set.
+	Hash string `json:",omitempty"`
+}
+
+// Options tunes an Analyzer. The zero value uses the defaults.
+type Options struct {
+	// BufferSize is the read chunk size in bytes (default 64 KiB).
+	BufferSize int
+	// Hash names a content digest to compute in the same pass: sha256, sha1,
+	// md5 or crc32. Binary files are then read to the end as well.
+	Hash string
+	// MaxLineLength, if positive, records the numbers of longer lines in
+	// FileStats.LongLines.
+	MaxLineLength int
+	// CharMode selects what FileStats.Chars counts: runes (the default),
+	// graphemes (user-perceived characters) or bytes in the file's encoding.
+	CharMode string
+	// Prose computes prose metrics for every text file, not only .md and
+	// .txt files.
+	Prose bool
+}
+
+// Analyzer computes FileStats. It is safe for concurrent use. Every method
+// stops reading between chunks once its context is canceled.
+type Analyzer struct {
+	opts Options
+}
+
+// New returns an Analyzer configured by opts.
+func New(opts Options) *Analyzer {
+	return &Analyzer{opts: opts}
+}
+
+// AnalyzePath analyzes the file at path on the local filesystem.
+func (a *Analyzer) AnalyzePath(ctx context.Context, path string) (FileStats, error) {
+	return result(analyze.AnalyzeFile(ctx, path, analyze.Options(a.opts)))
+}
+
+// AnalyzeReader analyzes everything read from r. name is used for the
+// extension and language detection; the size is the number of bytes read.
+func (a *Analyzer) AnalyzeReader(ctx context.Context, r io.Reader, name string) (FileStats, error) {
+	return result(analyze.AnalyzeReader(ctx, r, name, analyze.Options(a.opts)))
+}
+
+// AnalyzeFSFile analyzes the file name within fsys.
+func (a *Analyzer) AnalyzeFSFile(ctx context.Context, fsys fs.FS, name string) (FileStats, error) {
+	return result(analyze.AnalyzeFS(ctx, fsys, name, analyze.Options(a.opts)))
+}
+
+// WalkFS analyzes every regular file under root in fsys, in lexical order.
+// Per-file failures are reported in the returned stats; the error is non-nil
+// only if the walk itself fails or ctx is canceled.
+func (a *Analyzer) WalkFS(ctx context.Context, fsys fs.FS, root string) ([]FileStats, error) {
+	var out []FileStats
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			stat, err := a.AnalyzeFSFile(ctx, fsys, name)
+			if err != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			out = append(out, stat)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// result converts the engine's stats. FileStats and Options mirror the
+// internal types field for field, so the conversions stop compiling if the
+// two drift apart.
+func result(s analyze.FileStats, err error) (FileStats, error) {
+	return FileStats(s), err
+}
@@ -1,216 +1,435 @@
 package analyze
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"sync"
-	"unicode"
+	"strings"
 	"unicode/utf8"
 
+	"github.com/ADJB1212/Aperio/internal/grapheme"
+	"github.com/ADJB1212/Aperio/internal/lang"
 	"github.com/ADJB1212/Aperio/internal/util"
 )
 
 type FileStats struct {
-	Name      string
-	Ext       string
-	Kind      string
+	Name     string
+	Path     string
+	Ext      string
+	Kind     string
+	Language string
+	// MIME and Type identify the format from its magic number, e.g.
+	// "image/png" and "PNG image"; other content is text/plain or data.
+	MIME string
+	Type string
+	// Encoding is the detected text encoding, e.g. UTF-8 or UTF-16LE; it is
+	// empty for binary files.
+	Encoding  string
 	SizeBytes int64
 	Size      string
-	Lines     int
-	Words     int
-	Chars     int
-	ModTime   string
-	ModUnix   int64
-	HasError  bool
-	ErrorText string
+	// Lines counts the lines ended by LF, CRLF or a lone CR, plus a final
+	// line without a line ending. The SLOC, line length, indentation and
+	// prose metrics number and split lines the same way.
+	Lines int
+	// CodeLines, CommentLines and BlankLines break Lines down for files with
+	// a detected Language; they are zero otherwise.
+	CodeLines    int
+	CommentLines int
+	BlankLines   int
+	Words        int
+	// Chars counts runes, grapheme clusters or bytes after any BOM, as
+	// selected by Options.CharMode.
+	Chars int
+	// LF, CRLF and CR count each kind of line ending; EOL summarizes them
+	// as lf, crlf, cr, mixed or none. FinalNewline is also true for empty
+	// files, and TrailingSpaceLines counts lines ending in spaces or tabs.
+	// All are zero values for binary files.
+	LF                 int
+	CRLF               int
+	CR                 int
+	EOL                string
+	HasBOM             bool
+	FinalNewline       bool
+	TrailingSpaceLines int
+	// Line lengths exclude line endings and are measured in runes and in
+	// terminal cells (tabs advance to the next multiple of 8). LongestLine
+	// is the number of the first line with MaxLineLength runes.
+	MaxLineLength  int
+	MeanLineLength float64
+	P95LineLength  int
+	MaxLineWidth   int
+	MeanLineWidth  float64
+	P95LineWidth   int
+	LongestLine    int
+	// LongLines lists the lines longer than Options.MaxLineLength runes.
+	LongLines []int `json:",omitempty"`
+	// IndentStyle is tabs, spaces, mixed or none. IndentWidth is the inferred
+	// number of spaces per level (0 without space indentation), and depths
+	// count levels over non-blank lines, a tab being one level.
+	// MixedIndentLines counts lines indented with both tabs and spaces.
+	IndentStyle      string
+	IndentWidth      int
+	MixedIndentLines int
+	MaxIndentDepth   int
+	MeanIndentDepth  float64
+	// Prose is set when prose metrics were computed (Options.Prose, or .md
+	// and .txt files). ReadingEase is the Flesch Reading Ease score,
+	// GradeLevel the Flesch-Kincaid grade and ReadingMinutes the time to
+	// read the words at 238 per minute.
+	Prose          bool
+	Sentences      int
+	Paragraphs     int
+	Syllables      int
+	ReadingEase    float64
+	GradeLevel     float64
+	ReadingMinutes float64
+	ModTime        string
+	ModUnix        int64
+	HasError       bool
+	ErrorText      string
+	// Hash is the hex content digest when Options.Hash is set.
+	Hash string `json:",omitempty"`
 }
 
+// sniffSize is how much of a file is inspected to tell text from binary.
+const sniffSize = 8192
+
 func HumanBytes(bytes int64) string {
 	return util.HumanBytes(bytes)
 }
 
-func AnalyzeFile(path string, out chan<- FileStats, wg *sync.WaitGroup) {
-	defer wg.Done()
-	stat := FileStats{Name: filepath.Base(path), Ext: filepath.Ext(path)}
+// SniffKind reports whether the file at path is "text" or "binary" by
+// inspecting only its first few KiB.
+func SniffKind(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sniff := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, sniff)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, ok := detectEncoding(sniff[:n]); !ok {
+		return "binary", nil
+	}
+	return "text", nil
+}
+
+// isBinary reports whether a file prefix contains NUL bytes or invalid UTF-8.
+// An incomplete rune at the very end is tolerated since the prefix may cut it.
+func isBinary(sniff []byte) bool {
+	// Quick NUL check
+	for _, b := range sniff {
+		if b == 0x00 {
+			return true
+		}
+	}
+	// UTF-8 sanity check
+	i := 0
+	for i < len(sniff) {
+		r, size := utf8.DecodeRune(sniff[i:])
+		if r == utf8.RuneError && size == 1 {
+			if !utf8.FullRune(sniff[i:]) {
+				// Incomplete at end; stop checking.
+				break
+			}
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// Options tunes the analysis engine. The zero value uses the defaults.
+type Options struct {
+	// BufferSize is the read chunk size in bytes (default 64 KiB).
+	BufferSize int
+	// Hash names a content digest to compute in the same pass: sha256, sha1,
+	// md5 or crc32. Binary files are then read to the end as well.
+	Hash string
+	// MaxLineLength, if positive, records the numbers of longer lines in
+	// FileStats.LongLines.
+	MaxLineLength int
+	// CharMode selects what FileStats.Chars counts: runes (the default),
+	// graphemes (user-perceived characters) or bytes in the file's encoding.
+	CharMode string
+	// Prose computes prose metrics for every text file, not only .md and
+	// .txt files.
+	Prose bool
+}
+
+func (o Options) bufferSize() int {
+	if o.BufferSize <= 0 {
+		return 64 * 1024
+	}
+	return max(o.BufferSize, utf8.UTFMax)
+}
+
+// AnalyzeFile analyzes the file at path. Failures are reported through
+// HasError and ErrorText on the returned stats and also returned as an error.
+// Reading stops between chunks once ctx is canceled.
+func AnalyzeFile(ctx context.Context, path string, opts Options) (FileStats, error) {
+	stat := FileStats{Name: filepath.Base(path), Path: path, Ext: filepath.Ext(path)}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fail(stat, err)
+	}
+	setInfo(&stat, info)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fail(stat, err)
+	}
+	defer f.Close()
+
+	if err := analyzeContent(ctx, &stat, f, true, opts); err != nil {
+		return fail(stat, err)
+	}
+	return stat, nil
+}
+
+// AnalyzeFS analyzes the file name within fsys.
+func AnalyzeFS(ctx context.Context, fsys fs.FS, name string, opts Options) (FileStats, error) {
+	stat := FileStats{Name: path.Base(name), Path: name, Ext: path.Ext(name)}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fail(stat, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fail(stat, err)
+	}
+	if info.IsDir() {
+		return fail(stat, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")})
+	}
+	setInfo(&stat, info)
+
+	if err := analyzeContent(ctx, &stat, f, true, opts); err != nil {
+		return fail(stat, err)
+	}
+	return stat, nil
+}
+
+// AnalyzeReader analyzes everything read from r. name supplies the file name
+// used for the extension and language; size is taken from the bytes read.
+func AnalyzeReader(ctx context.Context, r io.Reader, name string, opts Options) (FileStats, error) {
+	stat := FileStats{Name: filepath.Base(name), Path: name, Ext: filepath.Ext(name)}
+	if err := analyzeContent(ctx, &stat, r, false, opts); err != nil {
+		return fail(stat, err)
+	}
+	return stat, nil
+}
 
+// StatFile fills in the name, size and modification time of the file at path
+// without reading it.
+func StatFile(path string) (FileStats, error) {
+	stat := FileStats{Name: filepath.Base(path), Path: path, Ext: filepath.Ext(path)}
 	info, err := os.Stat(path)
 	if err != nil {
-		stat.HasError = true
-		stat.ErrorText = err.Error()
-		out <- stat
-		return
+		return fail(stat, err)
 	}
+	setInfo(&stat, info)
+	return stat, nil
+}
+
+func fail(stat FileStats, err error) (FileStats, error) {
+	stat.HasError = true
+	stat.ErrorText = err.Error()
+	return stat, err
+}
 
+func setInfo(stat *FileStats, info fs.FileInfo) {
 	stat.SizeBytes = info.Size()
 	stat.Size = HumanBytes(info.Size())
 	stat.ModTime = info.ModTime().Format("2006-01-02 15:04:05")
 	stat.ModUnix = info.ModTime().Unix()
+}
 
-	f, err := os.Open(path)
+// analyzeContent fills in kind, language and text counts from a single pass
+// over r. When sized is false the size is measured from the bytes read.
+func analyzeContent(ctx context.Context, stat *FileStats, r io.Reader, sized bool, opts Options) error {
+	// Every byte passes through the digest and the size count exactly once.
+	h, err := newHash(opts.Hash)
 	if err != nil {
-		stat.HasError = true
-		stat.ErrorText = err.Error()
-		out <- stat
-		return
+		return err
 	}
-	defer f.Close()
+	if h != nil {
+		r = io.TeeReader(r, h)
+	}
+	cr := &countingReader{r: r}
+	r = cr
+	defer func() {
+		if !sized {
+			stat.SizeBytes = cr.n
+			stat.Size = HumanBytes(cr.n)
+		}
+	}()
 
-	// Detect binary files by scanning a small prefix for NUL bytes or invalid UTF-8.
-	// If binary, skip expensive text scanning.
+	buf := make([]byte, opts.bufferSize())
+
+	// Detect the text encoding from a small prefix, and identify the format
+	// from its magic number. If binary, skip expensive text scanning.
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	head = head[:n]
+	enc, isText := detectEncoding(head)
+	ft := detectType(head, isText)
+	stat.MIME, stat.Type = ft.mime, ft.typ
 	stat.Kind = "text"
-	if stat.SizeBytes > 0 {
-		const sniffSize = 8192
-		toRead := min(stat.SizeBytes, int64(sniffSize))
-		sniff := make([]byte, toRead)
-		if n, _ := f.ReadAt(sniff, 0); n > 0 {
-			isBin := false
-			// Quick NUL check
-			for i := range n {
-				if sniff[i] == 0x00 {
-					isBin = true
-					break
-				}
-			}
-			// UTF-8 sanity check
-			if !isBin {
-				i := 0
-				invalid := 0
-				for i < n {
-					r, size := utf8.DecodeRune(sniff[i:n])
-					if r == utf8.RuneError && size == 1 {
-						if !utf8.FullRune(sniff[i:n]) {
-							// Incomplete at end; stop checking.
-							break
-						}
-						invalid++
-						i++
-					} else {
-						i += size
-					}
-					if invalid > 0 {
-						isBin = true
-						break
-					}
-				}
-			}
-			if isBin {
-				stat.Kind = "binary"
-				stat.Lines = 0
-				stat.Words = 0
-				stat.Chars = 0
-				out <- stat
-				return
+	if !isText {
+		stat.Kind = "binary"
+		// Only read on when the rest of the content matters.
+		if h != nil || !sized {
+			if err := drain(ctx, r, buf); err != nil {
+				return err
 			}
 		}
+		setHash(stat, h)
+		return nil
 	}
 
-	lines, words, chars := 0, 0, 0
-	inWord := false
-	lastWasNewline := false
+	stat.Encoding = enc.name
 
-	buf := make([]byte, 64*1024)
-	var leftover [4]byte
-	leftN := 0
+	// The same prefix identifies the language from modelines and shebangs.
+	l := lang.Detect(stat.Name, enc.decodeHead(head))
+	if l != nil {
+		stat.Language = l.Name
+	}
+	c := counter{sloc: newSLOC(l), lineLen: lineStats{limit: opts.MaxLineLength}, indent: newIndentStats()}
+	if opts.Prose || proseExts[strings.ToLower(stat.Ext)] {
+		c.prose = newProseStats(l != nil && l.Name == "Markdown")
+	}
+	if opts.CharMode == "graphemes" {
+		c.seg = new(grapheme.Segmenter)
+	}
 
+	// The sniffed prefix (after any BOM) is decoded first, then the rest of
+	// the stream. An incomplete sequence at the end of a chunk is moved to the
+	// front of the buffer and completed by the next read.
+	src := io.MultiReader(bytes.NewReader(head[enc.bom:]), r)
+	dec := enc.decode
+	carry := 0
 	for {
-		n, err := f.Read(buf)
-		if n > 0 {
-			i := 0
-
-			// Complete a partial UTF-8 rune from previous chunk, if any.
-			if leftN > 0 {
-				need := min(4-leftN, n)
-				copy(leftover[leftN:], buf[:need])
-				seq := leftover[:leftN+need]
-				r, size := utf8.DecodeRune(seq)
-				if r == utf8.RuneError && size == 1 && !utf8.FullRune(seq) {
-					// Still incomplete; carry over and continue with next read.
-					leftN += need
-					i = need
-				} else {
-					chars++
-					if r == '\n' {
-						lines++
-						inWord = false
-						lastWasNewline = true
-					} else {
-						lastWasNewline = false
-						if unicode.IsSpace(r) {
-							if inWord {
-								inWord = false
-							}
-						} else {
-							if !inWord {
-								words++
-								inWord = true
-							}
-						}
-					}
-					i = size - leftN
-					leftN = 0
-				}
-			}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := src.Read(buf[carry:])
+		n += carry
+		carry = 0
 
-			// Process current chunk.
-			for i < n {
-				r, size := utf8.DecodeRune(buf[i:n])
+		i := 0
+		for i < n {
+			var r rune
+			var size int
+			if dec == nil {
+				r, size = utf8.DecodeRune(buf[i:n])
 				if r == utf8.RuneError && size == 1 && !utf8.FullRune(buf[i:n]) {
-					// Incomplete rune at end of buffer; stash for next read.
-					leftN = copy(leftover[:], buf[i:n])
-					i = n
-					break
-				}
-				chars++
-				if r == '\n' {
-					lines++
-					inWord = false
-					lastWasNewline = true
-				} else {
-					lastWasNewline = false
-					if unicode.IsSpace(r) {
-						if inWord {
-							inWord = false
-						}
-					} else {
-						if !inWord {
-							words++
-							inWord = true
-						}
-					}
+					size = 0
 				}
-				i += size
+			} else {
+				r, size = dec(buf[i:n])
+			}
+			if size == 0 {
+				// Incomplete rune at end of buffer; stash for next read.
+				carry = copy(buf, buf[i:n])
+				break
 			}
+			c.add(r)
+			i += size
 		}
 
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			stat.HasError = true
-			stat.ErrorText = err.Error()
-			out <- stat
-			return
+			return err
 		}
 	}
 
-	// Handle any incomplete UTF-8 sequence at EOF as a single replacement rune.
-	if leftN > 0 {
-		chars++
-		lastWasNewline = false
-		if !inWord {
-			words++
-			inWord = true
-		}
+	// Handle any incomplete sequence at EOF as a single replacement rune.
+	if carry > 0 {
+		c.add(utf8.RuneError)
 	}
+	c.finish()
 
-	// Count the final line if the file doesn't end with a newline and has content.
-	if chars > 0 && !lastWasNewline {
-		lines++
+	stat.Lines = c.lines
+	stat.Words = c.words
+	switch opts.CharMode {
+	case "graphemes":
+		stat.Chars = c.graphemes
+	case "bytes":
+		stat.Chars = int(cr.n) - enc.bom
+	default:
+		stat.Chars = c.chars
+	}
+	if c.sloc != nil {
+		stat.CodeLines = c.sloc.code
+		stat.CommentLines = c.sloc.comment
+		stat.BlankLines = c.sloc.blank
+	}
+	hy := &c.hygiene
+	stat.LF, stat.CRLF, stat.CR = hy.lf, hy.crlf, hy.cr
+	stat.EOL = hy.eol()
+	stat.HasBOM = enc.bom > 0
+	stat.FinalNewline = c.chars == 0 || hy.endsWithNewline()
+	stat.TrailingSpaceLines = hy.trailing
+	ll := &c.lineLen
+	stat.MaxLineLength, stat.MaxLineWidth, stat.LongestLine = ll.maxRunes, ll.maxCells, ll.longest
+	stat.MeanLineLength, stat.MeanLineWidth = ll.mean(ll.sumRunes), ll.mean(ll.sumCells)
+	stat.P95LineLength, stat.P95LineWidth = ll.runeHist.percentile(95), ll.cellHist.percentile(95)
+	stat.LongLines = ll.long
+	in := &c.indent
+	stat.IndentStyle, stat.IndentWidth, stat.MixedIndentLines = in.style(), in.width(), in.mixedLines
+	stat.MaxIndentDepth, stat.MeanIndentDepth = in.depth()
+	if p := c.prose; p != nil {
+		stat.Prose = true
+		stat.Sentences, stat.Paragraphs, stat.Syllables = p.sentences, p.paragraphs, p.syllables
+		stat.ReadingEase, stat.GradeLevel = p.scores()
+		stat.ReadingMinutes = p.readingMinutes()
 	}
+	setHash(stat, h)
+	return nil
+}
 
-	stat.Lines = lines
-	stat.Words = words
-	stat.Chars = chars
-	out <- stat
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// drain reads r to EOF, checking ctx between chunks.
+func drain(ctx context.Context, r io.Reader, buf []byte) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		_, err := r.Read(buf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
 }
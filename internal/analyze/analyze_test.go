@@ -0,0 +1,59 @@
+package analyze
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCharMode(t *testing.T) {
+	tests := []struct {
+		in    string
+		mode  string
+		chars int
+	}{
+		{"e\u0301 \U0001f1e9\U0001f1ea\n", "", 6},
+		{"e\u0301 \U0001f1e9\U0001f1ea\n", "runes", 6},
+		{"e\u0301 \U0001f1e9\U0001f1ea\n", "graphemes", 4},
+		{"e\u0301 \U0001f1e9\U0001f1ea\n", "bytes", 13},
+		{"a\r\nb", "graphemes", 3},
+		// Bytes are counted in the file's encoding, after the BOM.
+		{"\xef\xbb\xbfh\u00e9", "bytes", 3},
+		{"\xff\xfeh\x00i\x00", "bytes", 4},
+		{"caf\xe9\n", "bytes", 5},
+	}
+	for _, tt := range tests {
+		st, err := AnalyzeReader(context.Background(), strings.NewReader(tt.in), "f", Options{CharMode: tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Chars != tt.chars {
+			t.Errorf("%+q in %q mode: Chars = %d, want %d", tt.in, tt.mode, st.Chars, tt.chars)
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		name, path, in              string
+		lines, code, comment, blank int
+		longest, paragraphs         int
+	}{
+		{"mixed", "f", "a\rb\r\nc\n", 3, 0, 0, 0, 1, 0},
+		{"unterminated", "f", "a\r\rb", 3, 0, 0, 0, 1, 0},
+		{"sloc", "f.go", "// c\r\rx := 1\r", 3, 1, 1, 1, 3, 0},
+		{"prose", "f.txt", "One.\r\rTwo.\r", 3, 0, 0, 0, 1, 2},
+	}
+	for _, tt := range tests {
+		st, err := AnalyzeReader(context.Background(), strings.NewReader(tt.in), tt.path, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Lines != tt.lines || st.CodeLines != tt.code || st.CommentLines != tt.comment ||
+			st.BlankLines != tt.blank || st.LongestLine != tt.longest || st.Paragraphs != tt.paragraphs {
+			t.Errorf("%s: lines=%d code=%d comment=%d blank=%d longest=%d paragraphs=%d, want %d %d %d %d %d %d",
+				tt.name, st.Lines, st.CodeLines, st.CommentLines, st.BlankLines, st.LongestLine, st.Paragraphs,
+				tt.lines, tt.code, tt.comment, tt.blank, tt.longest, tt.paragraphs)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package analyze
+
+import (
+	"unicode"
+
+	"github.com/ADJB1212/Aperio/internal/grapheme"
+)
+
+// counter accumulates text metrics one decoded rune at a time.
+type counter struct {
+	lines, words, chars int
+	inWord              bool
+	lastWasNewline      bool
+	// prevCR is set after a '\r', whose line a following '\n' completes.
+	prevCR bool
+
+	hygiene hygiene
+	lineLen lineStats
+	indent  indentStats
+	// sloc is nil when the file's language is unknown.
+	sloc *slocCounter
+	// prose is nil unless prose metrics are computed.
+	prose *proseStats
+	// seg is nil unless grapheme clusters are counted.
+	seg       *grapheme.Segmenter
+	graphemes int
+}
+
+func (c *counter) add(r rune) {
+	c.chars++
+	// LF, CRLF and a lone CR each end one line, as in every line-based
+	// metric.
+	if r == '\n' || r == '\r' {
+		if !(c.prevCR && r == '\n') {
+			c.lines++
+		}
+		c.inWord = false
+		c.lastWasNewline = true
+	} else {
+		c.lastWasNewline = false
+		if unicode.IsSpace(r) {
+			c.inWord = false
+		} else if !c.inWord {
+			c.words++
+			c.inWord = true
+		}
+	}
+	c.prevCR = r == '\r'
+	c.hygiene.add(r)
+	c.lineLen.add(r)
+	c.indent.add(r)
+	if c.prose != nil {
+		c.prose.add(r)
+	}
+	if c.seg != nil && c.seg.Boundary(r) {
+		c.graphemes++
+	}
+	if c.sloc != nil {
+		c.sloc.add(r)
+	}
+}
+
+// finish settles counts that depend on how the input ended.
+func (c *counter) finish() {
+	// Count the final line if the file doesn't end with a newline and has content.
+	partial := c.chars > 0 && !c.lastWasNewline
+	if partial {
+		c.lines++
+	}
+	c.hygiene.finish()
+	c.lineLen.finish()
+	if c.sloc != nil {
+		c.sloc.finish()
+	}
+	if c.prose != nil {
+		c.prose.finish()
+	}
+}
@@ -0,0 +1,210 @@
+package analyze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// decodeFunc decodes the first rune of p and its length in bytes. It
+// returns size 0 when p holds only the start of a rune.
+type decodeFunc func(p []byte) (r rune, size int)
+
+// encoding is a detected text encoding.
+type encoding struct {
+	name string
+	// bom is the length of the byte order mark the content starts with.
+	bom int
+	// decode is nil for UTF-8, which is decoded inline.
+	decode decodeFunc
+}
+
+var (
+	encUTF8    = encoding{name: "UTF-8"}
+	encUTF16LE = encoding{name: "UTF-16LE", decode: utf16Decoder(binary.LittleEndian)}
+	encUTF16BE = encoding{name: "UTF-16BE", decode: utf16Decoder(binary.BigEndian)}
+	encUTF32LE = encoding{name: "UTF-32LE", decode: utf32Decoder(binary.LittleEndian)}
+	encUTF32BE = encoding{name: "UTF-32BE", decode: utf32Decoder(binary.BigEndian)}
+	encLatin1  = encoding{name: "ISO-8859-1", decode: decodeLatin1}
+	encCP1252  = encoding{name: "windows-1252", decode: decodeCP1252}
+)
+
+// detectEncoding identifies the text encoding of a file from its head, or
+// returns false if the content looks binary. A byte order mark decides;
+// otherwise valid UTF-8 wins, then NUL-byte patterns typical of UTF-32 and
+// UTF-16 text, then single-byte text with a few high bytes.
+func detectEncoding(head []byte) (encoding, bool) {
+	boms := []struct {
+		mark string
+		enc  encoding
+	}{
+		// UTF-32LE first: its mark starts with the UTF-16LE one.
+		{"\xff\xfe\x00\x00", encUTF32LE},
+		{"\x00\x00\xfe\xff", encUTF32BE},
+		{"\xef\xbb\xbf", encUTF8},
+		{"\xff\xfe", encUTF16LE},
+		{"\xfe\xff", encUTF16BE},
+	}
+	for _, b := range boms {
+		if bytes.HasPrefix(head, []byte(b.mark)) {
+			enc := b.enc
+			enc.bom = len(b.mark)
+			return enc, true
+		}
+	}
+
+	if !isBinary(head) {
+		return encUTF8, true
+	}
+	// A known binary signature beats the heuristics below, unless it is weak
+	// and the content also passes as single-byte text ("BMW", "MZ-80").
+	if _, weak, ok := matchSignature(head, false); ok {
+		if weak {
+			return detectSingleByte(head)
+		}
+		return encoding{}, false
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		// UTF-32 first: its units contain zero UTF-16 units.
+		switch {
+		case wideText(head, 4, binary.LittleEndian):
+			return encUTF32LE, true
+		case wideText(head, 4, binary.BigEndian):
+			return encUTF32BE, true
+		case wideText(head, 2, binary.LittleEndian):
+			return encUTF16LE, true
+		case wideText(head, 2, binary.BigEndian):
+			return encUTF16BE, true
+		}
+		return encoding{}, false
+	}
+	return detectSingleByte(head)
+}
+
+// wideText reports whether head looks like UTF-16 or UTF-32 text (size 2
+// or 4) in the given byte order: no NUL characters, no unit beyond the
+// Unicode range, and at least half of the units ASCII.
+func wideText(head []byte, size int, order binary.ByteOrder) bool {
+	units := len(head) / size
+	if units == 0 {
+		return false
+	}
+	ascii := 0
+	for i := 0; i+size <= len(head); i += size {
+		var u uint32
+		if size == 2 {
+			u = uint32(order.Uint16(head[i:]))
+		} else {
+			u = order.Uint32(head[i:])
+		}
+		switch {
+		case u == 0, u > utf8.MaxRune:
+			return false
+		case u < utf8.RuneSelf:
+			ascii++
+		}
+	}
+	return ascii*2 >= units
+}
+
+// detectSingleByte accepts text in Latin-1 or Windows-1252: no control
+// bytes other than whitespace, escape and a DOS end-of-file mark, and high
+// bytes in at most a third of the content. Bytes 0x80-0x9F are printable
+// only in Windows-1252, so any of them selects it.
+func detectSingleByte(head []byte) (encoding, bool) {
+	high, c1 := 0, false
+	for _, b := range head {
+		switch {
+		case b < 0x20 && b != '\t' && b != '\n' && b != '\v' && b != '\f' && b != '\r' && b != 0x1a && b != 0x1b, b == 0x7f:
+			return encoding{}, false
+		case b >= 0x80:
+			high++
+			if b <= 0x9f {
+				c1 = true
+			}
+		}
+	}
+	if high*3 > len(head) {
+		return encoding{}, false
+	}
+	if c1 {
+		return encCP1252, true
+	}
+	return encLatin1, true
+}
+
+// decodeHead returns head (without its BOM) as UTF-8, for detectors that
+// read text. An incomplete rune at the end is dropped.
+func (e encoding) decodeHead(head []byte) []byte {
+	head = head[e.bom:]
+	if e.decode == nil {
+		return head
+	}
+	out := make([]byte, 0, len(head))
+	for len(head) > 0 {
+		r, size := e.decode(head)
+		if size == 0 {
+			break
+		}
+		out = utf8.AppendRune(out, r)
+		head = head[size:]
+	}
+	return out
+}
+
+func utf16Decoder(order binary.ByteOrder) decodeFunc {
+	return func(p []byte) (rune, int) {
+		if len(p) < 2 {
+			return 0, 0
+		}
+		r := rune(order.Uint16(p))
+		if !utf16.IsSurrogate(r) {
+			return r, 2
+		}
+		if r >= 0xdc00 {
+			// Low surrogate without a high one.
+			return utf8.RuneError, 2
+		}
+		if len(p) < 4 {
+			return 0, 0
+		}
+		if r = utf16.DecodeRune(r, rune(order.Uint16(p[2:]))); r == utf8.RuneError {
+			return r, 2
+		}
+		return r, 4
+	}
+}
+
+func utf32Decoder(order binary.ByteOrder) decodeFunc {
+	return func(p []byte) (rune, int) {
+		if len(p) < 4 {
+			return 0, 0
+		}
+		r := rune(order.Uint32(p))
+		if !utf8.ValidRune(r) {
+			r = utf8.RuneError
+		}
+		return r, 4
+	}
+}
+
+func decodeLatin1(p []byte) (rune, int) {
+	return rune(p[0]), 1
+}
+
+// cp1252 maps Windows-1252 bytes 0x80-0x9F; the five unassigned bytes keep
+// their Latin-1 (C1 control) values.
+var cp1252 = [32]rune{
+	0x20ac, 0x0081, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008d, 0x017d, 0x008f,
+	0x0090, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0x009d, 0x017e, 0x0178,
+}
+
+func decodeCP1252(p []byte) (rune, int) {
+	if b := p[0]; b >= 0x80 && b <= 0x9f {
+		return cp1252[b-0x80], 1
+	}
+	return rune(p[0]), 1
+}
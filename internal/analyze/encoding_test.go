@@ -0,0 +1,128 @@
+package analyze
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want string // "" for binary
+		bom  int
+	}{
+		{"empty", "", "UTF-8", 0},
+		{"ascii", "hello\n", "UTF-8", 0},
+		{"utf-8", "héllo wörld\n", "UTF-8", 0},
+		{"utf-8 bom", "\xef\xbb\xbfhi", "UTF-8", 3},
+		{"utf-16le bom", "\xff\xfeh\x00i\x00", "UTF-16LE", 2},
+		{"utf-16be bom", "\xfe\xff\x00h\x00i", "UTF-16BE", 2},
+		{"utf-32le bom", "\xff\xfe\x00\x00h\x00\x00\x00", "UTF-32LE", 4},
+		{"utf-32be bom", "\x00\x00\xfe\xff\x00\x00\x00h", "UTF-32BE", 4},
+
+		{"utf-16le", "h\x00i\x00\n\x00", "UTF-16LE", 0},
+		{"utf-16be", "\x00h\x00i\x00\n", "UTF-16BE", 0},
+		{"utf-32le", "h\x00\x00\x00i\x00\x00\x00", "UTF-32LE", 0},
+		{"utf-32be", "\x00\x00\x00h\x00\x00\x00i", "UTF-32BE", 0},
+		// Without a BOM, UTF-16 needs mostly ASCII to be told from binary.
+		{"utf-16le cjk", "\x2d\x4e\x00\x4e\x87\x65", "", 0},
+		{"utf-32 before utf-16", "h\x00\x00\x00i\x00", "UTF-32LE", 0},
+		{"binary nul", "\x00\x00\x01\x02", "", 0},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "", 0},
+		// Short signatures only count when the content is not text.
+		{"bmp", "BM\x36\x00\x0c\x00\x00\x00\x00\x00\x36\x00\x00\x00", "", 0},
+		{"bmw", "BMW M3 f\xfcr M\xfcnchen\n", "ISO-8859-1", 0},
+		{"dos", "MZ\x90\x00\x03\x00\x00\x00\x04\x00", "", 0},
+		{"mz text", "MZ-80 caf\xe9\n", "ISO-8859-1", 0},
+		{"id3 text", "ID3 tags \x93explained\x94\n", "windows-1252", 0},
+		{"bzip2", "BZh91AY&SY\x8f\x12\xe3\xa7", "", 0},
+
+		{"latin-1", "caf\xe9 cr\xe8me\n", "ISO-8859-1", 0},
+		{"cp1252 quotes", "\x93quoted\x94 caf\xe9\n", "windows-1252", 0},
+		{"cp1252 euro", "5 \x80\n", "windows-1252", 0},
+		{"dos eof", "caf\xe9\r\n\x1a", "ISO-8859-1", 0},
+		{"mostly high", "\xe9\xe9\xe9a", "", 0},
+		{"control byte", "abc\x01\xe9z", "", 0},
+		{"del", "abc\x7f\xe9z", "", 0},
+	}
+	for _, tt := range tests {
+		enc, ok := detectEncoding([]byte(tt.head))
+		got := ""
+		if ok {
+			got = enc.name
+		}
+		if got != tt.want || enc.bom != tt.bom {
+			t.Errorf("%s: detectEncoding = %q (bom %d), want %q (bom %d)", tt.name, got, enc.bom, tt.want, tt.bom)
+		}
+	}
+}
+
+func TestDecoders(t *testing.T) {
+	tests := []struct {
+		name string
+		dec  decodeFunc
+		in   string
+		r    rune
+		size int
+	}{
+		{"utf-16le", encUTF16LE.decode, "h\x00", 'h', 2},
+		{"utf-16be", encUTF16BE.decode, "\x00h", 'h', 2},
+		{"utf-16 pair", encUTF16LE.decode, "\x3d\xd8\x00\xde", 0x1f600, 4},
+		{"utf-16 half pair", encUTF16LE.decode, "\x3d\xd8", 0, 0},
+		{"utf-16 odd byte", encUTF16LE.decode, "h", 0, 0},
+		{"utf-16 lone low", encUTF16LE.decode, "\x00\xdeh\x00", 0xfffd, 2},
+		{"utf-16 lone high", encUTF16LE.decode, "\x3d\xd8h\x00", 0xfffd, 2},
+		{"utf-32le", encUTF32LE.decode, "\x00\xf6\x01\x00", 0x1f600, 4},
+		{"utf-32 invalid", encUTF32BE.decode, "\x00\x11\x00\x00", 0xfffd, 4},
+		{"utf-32 short", encUTF32BE.decode, "\x00\x00\x00", 0, 0},
+		{"latin-1", decodeLatin1, "\xe9", 'é', 1},
+		{"latin-1 c1", decodeLatin1, "\x80", 0x80, 1},
+		{"cp1252 euro", decodeCP1252, "\x80", '€', 1},
+		{"cp1252 quote", decodeCP1252, "\x94", '”', 1},
+		{"cp1252 unassigned", decodeCP1252, "\x81", 0x81, 1},
+		{"cp1252 latin", decodeCP1252, "\xe9", 'é', 1},
+	}
+	for _, tt := range tests {
+		r, size := tt.dec([]byte(tt.in))
+		if r != tt.r || size != tt.size {
+			t.Errorf("%s: decode = %U, %d, want %U, %d", tt.name, r, size, tt.r, tt.size)
+		}
+	}
+}
+
+func TestAnalyzeReaderEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		kind     string
+		encoding string
+		lines    int
+		words    int
+		chars    int
+	}{
+		{"utf-8 bom", "\xef\xbb\xbfhi there\n", "text", "UTF-8", 1, 2, 9},
+		{"utf-16le bom", "\xff\xfeh\x00i\x00\n\x00", "text", "UTF-16LE", 1, 1, 3},
+		{"utf-16be", "\x00h\x00i\x00 \x00y\x00o", "text", "UTF-16BE", 1, 2, 5},
+		{"utf-16 emoji", "\xff\xfe\x3d\xd8\x00\xde\n\x00", "text", "UTF-16LE", 1, 1, 2},
+		{"utf-16 truncated", "\xff\xfeh\x00i\x00\x3d\xd8", "text", "UTF-16LE", 1, 1, 3},
+		{"latin-1", "caf\xe9 au lait\n", "text", "ISO-8859-1", 1, 3, 13},
+		{"cp1252", "\x93hi there\x94\n", "text", "windows-1252", 1, 2, 11},
+		{"latin-1 bm", "BMW f\xfcr alle\n", "text", "ISO-8859-1", 1, 3, 13},
+		{"binary", "\x00\x00\x01\x02", "binary", "", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		// A tiny buffer makes runes straddle reads.
+		st, err := AnalyzeReader(context.Background(), strings.NewReader(tt.in), "f", Options{BufferSize: 5})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if st.Kind != tt.kind || st.Encoding != tt.encoding || st.Lines != tt.lines || st.Words != tt.words || st.Chars != tt.chars {
+			t.Errorf("%s: got %s %q lines=%d words=%d chars=%d, want %s %q lines=%d words=%d chars=%d",
+				tt.name, st.Kind, st.Encoding, st.Lines, st.Words, st.Chars,
+				tt.kind, tt.encoding, tt.lines, tt.words, tt.chars)
+		}
+	}
+}
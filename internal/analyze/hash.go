@@ -0,0 +1,61 @@
+package analyze
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// hashes are the digests Options.Hash can name.
+var hashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+}
+
+// newHash returns the named digest, or nil if name is empty.
+func newHash(name string) (hash.Hash, error) {
+	if name == "" {
+		return nil, nil
+	}
+	h, ok := hashes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash %q", name)
+	}
+	return h(), nil
+}
+
+func setHash(stat *FileStats, h hash.Hash) {
+	if h != nil {
+		stat.Hash = hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// HashFile returns the hex digest of the file at path without analyzing it.
+// Reading stops between chunks once ctx is canceled.
+func HashFile(ctx context.Context, path string, name string, opts Options) (string, error) {
+	h, err := newHash(name)
+	if err != nil {
+		return "", err
+	}
+	if h == nil {
+		return "", fmt.Errorf("no hash selected")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := drain(ctx, io.TeeReader(f, h), make([]byte, opts.bufferSize())); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,77 @@
+package analyze
+
+// hygiene tracks line endings and trailing whitespace for one text file.
+type hygiene struct {
+	lf, crlf, cr int
+	trailing     int
+	// prevCR is set after a '\r' whose line ending is not settled yet.
+	prevCR bool
+	// space is set while the current line ends in spaces or tabs.
+	space bool
+	last  rune
+}
+
+func (h *hygiene) add(r rune) {
+	if h.prevCR {
+		h.prevCR = false
+		if r == '\n' {
+			h.crlf++
+			h.last = r
+			return
+		}
+		h.cr++
+	}
+	switch r {
+	case '\n':
+		h.lf++
+		h.endLine()
+	case '\r':
+		h.prevCR = true
+		h.endLine()
+	case ' ', '\t':
+		h.space = true
+	default:
+		h.space = false
+	}
+	h.last = r
+}
+
+func (h *hygiene) endLine() {
+	if h.space {
+		h.trailing++
+	}
+	h.space = false
+}
+
+// finish settles a trailing '\r' and a final line without a line ending.
+func (h *hygiene) finish() {
+	if h.prevCR {
+		h.prevCR = false
+		h.cr++
+	}
+	h.endLine()
+}
+
+// eol names the line ending style: "lf", "crlf", "cr", "mixed", or "none"
+// for content without line endings.
+func (h *hygiene) eol() string {
+	kinds, style := 0, "none"
+	for _, k := range []struct {
+		n    int
+		name string
+	}{{h.lf, "lf"}, {h.crlf, "crlf"}, {h.cr, "cr"}} {
+		if k.n > 0 {
+			kinds++
+			style = k.name
+		}
+	}
+	if kinds > 1 {
+		return "mixed"
+	}
+	return style
+}
+
+// endsWithNewline reports whether the last rune was a line ending.
+func (h *hygiene) endsWithNewline() bool {
+	return h.last == '\n' || h.last == '\r'
+}
@@ -0,0 +1,71 @@
+package analyze
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHygiene(t *testing.T) {
+	tests := []struct {
+		in           string
+		lf, crlf, cr int
+		eol          string
+		trailing     int
+		finalNewline bool
+	}{
+		{"", 0, 0, 0, "none", 0, false},
+		{"no newline", 0, 0, 0, "none", 0, false},
+		{"a\nb\n", 2, 0, 0, "lf", 0, true},
+		{"a\r\nb\r\n", 0, 2, 0, "crlf", 0, true},
+		{"a\rb\r", 0, 0, 2, "cr", 0, true},
+		{"a\nb\r\nc", 1, 1, 0, "mixed", 0, false},
+		{"a\rb\n", 1, 0, 1, "mixed", 0, true},
+		{"\r\r\n", 0, 1, 1, "mixed", 0, true},
+		{"\n\r", 1, 0, 1, "mixed", 0, true},
+
+		{"a \nb\t\nc", 2, 0, 0, "lf", 2, false},
+		{"a \r\nb\t\r\n", 0, 2, 0, "crlf", 2, true},
+		{"a \rb", 0, 0, 1, "cr", 1, false},
+		{"  \n", 1, 0, 0, "lf", 1, true},
+		{"a b\n", 1, 0, 0, "lf", 0, true},
+		{"last \t", 0, 0, 0, "none", 1, false},
+	}
+	for _, tt := range tests {
+		var h hygiene
+		for _, r := range tt.in {
+			h.add(r)
+		}
+		h.finish()
+		if h.lf != tt.lf || h.crlf != tt.crlf || h.cr != tt.cr || h.eol() != tt.eol ||
+			h.trailing != tt.trailing || h.endsWithNewline() != tt.finalNewline {
+			t.Errorf("%q: lf=%d crlf=%d cr=%d eol=%s trailing=%d final=%v, want %d %d %d %s %d %v",
+				tt.in, h.lf, h.crlf, h.cr, h.eol(), h.trailing, h.endsWithNewline(),
+				tt.lf, tt.crlf, tt.cr, tt.eol, tt.trailing, tt.finalNewline)
+		}
+	}
+}
+
+func TestAnalyzeReaderHygiene(t *testing.T) {
+	tests := []struct {
+		in           string
+		bom          bool
+		finalNewline bool
+		eol          string
+	}{
+		{"", false, true, "none"},
+		{"\xef\xbb\xbfa\n", true, true, "lf"},
+		{"\xff\xfea\x00\r\x00\n\x00", true, true, "crlf"},
+		{"a\nb", false, false, "lf"},
+	}
+	for _, tt := range tests {
+		st, err := AnalyzeReader(context.Background(), strings.NewReader(tt.in), "f", Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.HasBOM != tt.bom || st.FinalNewline != tt.finalNewline || st.EOL != tt.eol {
+			t.Errorf("%q: bom=%v final=%v eol=%s, want %v %v %s",
+				tt.in, st.HasBOM, st.FinalNewline, st.EOL, tt.bom, tt.finalNewline, tt.eol)
+		}
+	}
+}
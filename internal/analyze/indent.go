@@ -0,0 +1,108 @@
+package analyze
+
+// indentStats classifies the leading whitespace of every non-blank line.
+type indentStats struct {
+	// Leading whitespace of the current line; inIndent is set until its
+	// first other character.
+	tabs, spaces int
+	inIndent     bool
+
+	tabLines, spaceLines, mixedLines int
+	// levels counts lines by their (tabs, spaces) indentation; depths are
+	// worked out once the indent width is known.
+	levels map[[2]int]int
+	// steps counts increases in space indentation between consecutive
+	// lines, by size (1-8), to infer the indent width.
+	steps      [9]int
+	prevSpaces int
+}
+
+func newIndentStats() indentStats {
+	return indentStats{inIndent: true, levels: make(map[[2]int]int)}
+}
+
+func (s *indentStats) add(r rune) {
+	// LF, CRLF and a lone CR all end a line; resetting twice for CRLF is
+	// harmless.
+	if r == '\n' || r == '\r' {
+		s.tabs, s.spaces, s.inIndent = 0, 0, true
+		return
+	}
+	if !s.inIndent {
+		return
+	}
+	switch r {
+	case ' ':
+		s.spaces++
+	case '\t':
+		s.tabs++
+	default:
+		s.inIndent = false
+		s.record()
+	}
+}
+
+// record files the indentation of a line at its first non-blank character.
+func (s *indentStats) record() {
+	switch {
+	case s.tabs > 0 && s.spaces > 0:
+		s.mixedLines++
+	case s.tabs > 0:
+		s.tabLines++
+	case s.spaces > 0:
+		s.spaceLines++
+	}
+	s.levels[[2]int{s.tabs, s.spaces}]++
+	if s.tabs == 0 {
+		if d := s.spaces - s.prevSpaces; d > 0 && d < len(s.steps) {
+			s.steps[d]++
+		}
+		s.prevSpaces = s.spaces
+	}
+}
+
+// style is tabs, spaces, mixed (both kinds, or both within a line), or
+// none when no line is indented.
+func (s *indentStats) style() string {
+	switch {
+	case s.mixedLines > 0 || s.tabLines > 0 && s.spaceLines > 0:
+		return "mixed"
+	case s.tabLines > 0:
+		return "tabs"
+	case s.spaceLines > 0:
+		return "spaces"
+	}
+	return "none"
+}
+
+// width is the most common step between space-indented lines (the
+// smaller on a tie), or 0 if no line is indented with spaces.
+func (s *indentStats) width() int {
+	w := 0
+	for d := 1; d < len(s.steps); d++ {
+		if s.steps[d] > s.steps[w] {
+			w = d
+		}
+	}
+	return w
+}
+
+// depth returns the maximum and mean nesting level over non-blank lines.
+// A tab is one level, and so is each indent width of spaces.
+func (s *indentStats) depth() (maxDepth int, mean float64) {
+	w := s.width()
+	if w == 0 {
+		w = tabWidth
+	}
+	lines, sum := 0, 0
+	for k, n := range s.levels {
+		d := k[0] + k[1]/w
+		maxDepth = max(maxDepth, d)
+		sum += d * n
+		lines += n
+	}
+	if lines == 0 {
+		return 0, 0
+	}
+	return maxDepth, float64(sum) / float64(lines)
+}
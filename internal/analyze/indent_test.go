@@ -0,0 +1,45 @@
+package analyze
+
+import "testing"
+
+func TestIndentStats(t *testing.T) {
+	tests := []struct {
+		in        string
+		style     string
+		width     int
+		mixed     int
+		maxDepth  int
+		meanDepth float64
+	}{
+		{"", "none", 0, 0, 0, 0},
+		{"a\nb\n", "none", 0, 0, 0, 0},
+		{"a\n  b\n    c\n  d\n", "spaces", 2, 0, 2, 1},
+		{"a\n    b\n        c\n", "spaces", 4, 0, 2, 1},
+		{"a\n   b\n", "spaces", 3, 0, 1, 0.5},
+		// Blank lines are left out.
+		{"a\n    \n  b\n", "spaces", 2, 0, 1, 0.5},
+		// On a tie the smaller step wins.
+		{"a\n  b\nc\n    d\n", "spaces", 2, 0, 2, 0.75},
+		{"a\r\n  b\r\n", "spaces", 2, 0, 1, 0.5},
+		{"a\r  b\r    c\r", "spaces", 2, 0, 2, 1},
+		{"\ta\r\tb\r", "tabs", 0, 0, 1, 1},
+		{"a\n\tb\n\t\tc\n", "tabs", 0, 0, 2, 1},
+		{"a\n\tb\n  c\n", "mixed", 2, 0, 1, 2.0 / 3},
+		// Without a space width, eight spaces make a level.
+		{"\t  a\n", "mixed", 0, 1, 1, 1},
+		{"\t        a\n", "mixed", 0, 1, 2, 2},
+	}
+	for _, tt := range tests {
+		s := newIndentStats()
+		for _, r := range tt.in {
+			s.add(r)
+		}
+		maxDepth, mean := s.depth()
+		if s.style() != tt.style || s.width() != tt.width || s.mixedLines != tt.mixed ||
+			maxDepth != tt.maxDepth || mean != tt.meanDepth {
+			t.Errorf("%q: style=%s width=%d mixed=%d depth=%d/%v, want %s %d %d %d/%v",
+				tt.in, s.style(), s.width(), s.mixedLines, maxDepth, mean,
+				tt.style, tt.width, tt.mixed, tt.maxDepth, tt.meanDepth)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package analyze
+
+import (
+	"slices"
+
+	"github.com/ADJB1212/Aperio/internal/width"
+)
+
+// tabWidth is the tab stop used to measure display width.
+const tabWidth = 8
+
+// lineStats measures the length of every line in runes and in terminal
+// cells. Line endings are not counted; like hygiene, it ends lines at LF,
+// CRLF and a lone CR.
+type lineStats struct {
+	runes, cells int // current line
+	line         int // current line number, from 1
+	// open is set while the current line has content; prevCR is set after
+	// a '\r', so that a '\n' right after it does not end another line.
+	open, prevCR bool
+
+	maxRunes, maxCells int
+	sumRunes, sumCells int
+	longest            int
+	runeHist, cellHist histogram
+
+	// limit, if positive, records lines longer than limit runes in long.
+	limit int
+	long  []int
+}
+
+func (s *lineStats) add(r rune) {
+	if s.prevCR {
+		s.prevCR = false
+		if r == '\n' {
+			return
+		}
+	}
+	switch r {
+	case '\n':
+		s.endLine()
+	case '\r':
+		s.prevCR = true
+		s.endLine()
+	case '\t':
+		s.open = true
+		s.runes++
+		s.cells += tabWidth - s.cells%tabWidth
+	default:
+		s.open = true
+		s.runes++
+		s.cells += width.Rune(r)
+	}
+}
+
+func (s *lineStats) endLine() {
+	s.line++
+	if s.runes > s.maxRunes {
+		s.maxRunes, s.longest = s.runes, s.line
+	}
+	s.maxCells = max(s.maxCells, s.cells)
+	s.sumRunes += s.runes
+	s.sumCells += s.cells
+	s.runeHist.add(s.runes)
+	s.cellHist.add(s.cells)
+	if s.limit > 0 && s.runes > s.limit {
+		s.long = append(s.long, s.line)
+	}
+	s.runes, s.cells, s.open = 0, 0, false
+}
+
+// finish measures a final line that lacks a line ending.
+func (s *lineStats) finish() {
+	if s.open {
+		s.endLine()
+	}
+}
+
+func (s *lineStats) mean(sum int) float64 {
+	if s.line == 0 {
+		return 0
+	}
+	return float64(sum) / float64(s.line)
+}
+
+// histogram counts values for percentiles. Values up to histSmall are
+// counted in place; longer ones, rare in text, are kept as they are.
+type histogram struct {
+	small []int
+	large []int
+	n     int
+}
+
+const histSmall = 4096
+
+func (h *histogram) add(v int) {
+	h.n++
+	if v >= histSmall {
+		h.large = append(h.large, v)
+		return
+	}
+	if v >= len(h.small) {
+		h.small = append(h.small, make([]int, v+1-len(h.small))...)
+	}
+	h.small[v]++
+}
+
+// percentile returns the nearest-rank p-th percentile (0 < p <= 100).
+func (h *histogram) percentile(p int) int {
+	if h.n == 0 {
+		return 0
+	}
+	rank := (h.n*p + 99) / 100
+	for v, c := range h.small {
+		if rank -= c; rank <= 0 {
+			return v
+		}
+	}
+	slices.Sort(h.large)
+	return h.large[rank-1]
+}
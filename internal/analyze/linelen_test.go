@@ -0,0 +1,84 @@
+package analyze
+
+import (
+	"slices"
+	"testing"
+)
+
+// measure runs s through lineStats the way the counter does.
+func measure(s string, limit int) *lineStats {
+	ls := &lineStats{limit: limit}
+	for _, r := range s {
+		ls.add(r)
+	}
+	ls.finish()
+	return ls
+}
+
+func TestLineStats(t *testing.T) {
+	tests := []struct {
+		in                 string
+		lines              int
+		maxRunes, maxCells int
+		meanRunes          float64
+		longest            int
+		long               []int
+	}{
+		{"", 0, 0, 0, 0, 0, nil},
+		{"abc", 1, 3, 3, 3, 1, nil},
+		{"a\nabcd\nab\n", 3, 4, 4, 7.0 / 3, 2, []int{2}},
+		{"abcd\nabcd\n", 2, 4, 4, 4, 1, []int{1, 2}},
+		{"\n\n", 2, 0, 0, 0, 0, nil},
+		// Line endings are not counted, and LF, CRLF and a lone CR each end
+		// a line.
+		{"abc\r\nde\r\n", 2, 3, 3, 2.5, 1, nil},
+		{"ab\rabcd\r", 2, 4, 4, 3, 2, []int{2}},
+		{"a\r\r\nb", 3, 1, 1, 2.0 / 3, 1, nil},
+		{"abcd\n\r", 2, 4, 4, 2, 1, []int{1}},
+		// Tabs advance to the next multiple of 8; wide runes take two cells
+		// and combining marks none.
+		{"\tx\n", 1, 2, 9, 2, 1, nil},
+		{"ab\tx\n", 1, 4, 9, 4, 1, []int{1}},
+		{"日本語\n", 1, 3, 6, 3, 1, nil},
+		{"e\u0301\n", 1, 2, 1, 2, 1, nil},
+	}
+	for _, tt := range tests {
+		ls := measure(tt.in, 3)
+		if ls.line != tt.lines || ls.maxRunes != tt.maxRunes || ls.maxCells != tt.maxCells ||
+			ls.mean(ls.sumRunes) != tt.meanRunes || ls.longest != tt.longest || !slices.Equal(ls.long, tt.long) {
+			t.Errorf("%q: lines=%d max=%d/%d mean=%v longest=%d long=%v, want %d %d/%d %v %d %v",
+				tt.in, ls.line, ls.maxRunes, ls.maxCells, ls.mean(ls.sumRunes), ls.longest, ls.long,
+				tt.lines, tt.maxRunes, tt.maxCells, tt.meanRunes, tt.longest, tt.long)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []int
+		p      int
+		want   int
+	}{
+		{nil, 95, 0},
+		{[]int{7}, 95, 7},
+		{[]int{1, 2, 3, 4}, 50, 2},
+		{[]int{1, 2, 3, 4}, 51, 3},
+		{[]int{1, 2, 3, 4}, 100, 4},
+		{[]int{4, 3, 2, 1}, 75, 3},
+		// Nearest rank: 95% of 20 values is the 19th.
+		{[]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 95, 19},
+		// Values past histSmall are kept as they are.
+		{[]int{5, 10000, 5000, 9}, 50, 9},
+		{[]int{5, 10000, 5000, 9}, 75, 5000},
+		{[]int{5, 10000, 5000, 9}, 100, 10000},
+	}
+	for _, tt := range tests {
+		var h histogram
+		for _, v := range tt.values {
+			h.add(v)
+		}
+		if got := h.percentile(tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %d, want %d", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
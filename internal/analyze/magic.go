@@ -0,0 +1,258 @@
+package analyze
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// fileType is a detected MIME type and a human-readable description.
+type fileType struct {
+	mime string
+	typ  string
+}
+
+// Fallbacks when no signature matches.
+var (
+	plainText   = fileType{"text/plain", "text"}
+	unknownData = fileType{"application/octet-stream", "data"}
+)
+
+// signature is a magic number: bytes found at a fixed offset of a format.
+type signature struct {
+	offset int
+	magic  string
+	fileType
+	// text lets the signature match content that sniffed as text; most
+	// signatures are short enough to appear at the start of prose.
+	text bool
+	// refine inspects the head further. It may replace the type, or return
+	// false to reject the match.
+	refine func(head []byte, ft fileType) (fileType, bool)
+}
+
+// signatures are tried in order; the first match wins.
+var signatures = []signature{
+	{magic: "\x89PNG\r\n\x1a\n", fileType: fileType{"image/png", "PNG image"}},
+	{magic: "\xff\xd8\xff", fileType: fileType{"image/jpeg", "JPEG image"}},
+	{magic: "GIF87a", fileType: fileType{"image/gif", "GIF image"}},
+	{magic: "GIF89a", fileType: fileType{"image/gif", "GIF image"}},
+	{magic: "RIFF", refine: refineRIFF},
+	{magic: "II*\x00", fileType: fileType{"image/tiff", "TIFF image"}},
+	{magic: "MM\x00*", fileType: fileType{"image/tiff", "TIFF image"}},
+	{magic: "\x00\x00\x01\x00", fileType: fileType{"image/vnd.microsoft.icon", "Windows icon"}},
+	{magic: "8BPS", fileType: fileType{"image/vnd.adobe.photoshop", "Photoshop image"}},
+	{magic: "BM", fileType: fileType{"image/bmp", "BMP image"}},
+	{magic: "%PDF-", fileType: fileType{"application/pdf", "PDF document"}, text: true},
+	{magic: "%!PS", fileType: fileType{"application/postscript", "PostScript document"}, text: true},
+	{magic: "{\\rtf", fileType: fileType{"application/rtf", "RTF document"}, text: true},
+	{magic: "PK\x03\x04", fileType: fileType{"application/zip", "Zip archive"}, refine: refineZip},
+	{magic: "PK\x05\x06", fileType: fileType{"application/zip", "Zip archive"}},
+	{magic: "\x1f\x8b", fileType: fileType{"application/gzip", "gzip compressed data"}},
+	{magic: "BZh", fileType: fileType{"application/x-bzip2", "bzip2 compressed data"}},
+	{magic: "\xfd7zXZ\x00", fileType: fileType{"application/x-xz", "XZ compressed data"}},
+	{magic: "\x28\xb5\x2f\xfd", fileType: fileType{"application/zstd", "Zstandard compressed data"}},
+	{magic: "7z\xbc\xaf\x27\x1c", fileType: fileType{"application/x-7z-compressed", "7-zip archive"}},
+	{magic: "Rar!\x1a\x07", fileType: fileType{"application/vnd.rar", "RAR archive"}},
+	{magic: "MSCF", fileType: fileType{"application/vnd.ms-cab-compressed", "Microsoft Cabinet archive"}},
+	{offset: 257, magic: "ustar", fileType: fileType{"application/x-tar", "tar archive"}},
+	{magic: "!<arch>\ndebian", fileType: fileType{"application/vnd.debian.binary-package", "Debian package"}},
+	{magic: "!<arch>\n", fileType: fileType{"application/x-archive", "ar archive"}},
+	{magic: "\x7fELF", fileType: fileType{"application/x-executable", "ELF executable"}, refine: refineELF},
+	{magic: "\xfe\xed\xfa\xce", fileType: fileType{"application/x-mach-binary", "Mach-O binary"}},
+	{magic: "\xfe\xed\xfa\xcf", fileType: fileType{"application/x-mach-binary", "Mach-O binary"}},
+	{magic: "\xce\xfa\xed\xfe", fileType: fileType{"application/x-mach-binary", "Mach-O binary"}},
+	{magic: "\xcf\xfa\xed\xfe", fileType: fileType{"application/x-mach-binary", "Mach-O binary"}},
+	{magic: "\xca\xfe\xba\xbe", refine: refineCafebabe},
+	{magic: "MZ", fileType: fileType{"application/x-dosexec", "DOS executable"}, refine: refinePE},
+	{magic: "\x00asm", fileType: fileType{"application/wasm", "WebAssembly binary"}},
+	{magic: "SQLite format 3\x00", fileType: fileType{"application/vnd.sqlite3", "SQLite database"}},
+	{magic: "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", fileType: fileType{"application/x-ole-storage", "OLE2 compound document"}},
+	{offset: 4, magic: "ftyp", fileType: fileType{"video/mp4", "ISO media"}, refine: refineFtyp},
+	{magic: "OggS", fileType: fileType{"audio/ogg", "Ogg data"}},
+	{magic: "fLaC", fileType: fileType{"audio/flac", "FLAC audio"}},
+	{magic: "ID3", fileType: fileType{"audio/mpeg", "MP3 audio"}},
+	{magic: "MThd", fileType: fileType{"audio/midi", "MIDI audio"}},
+	{magic: "\x1aE\xdf\xa3", fileType: fileType{"video/x-matroska", "Matroska media"}},
+	{magic: "wOFF", fileType: fileType{"font/woff", "WOFF font"}},
+	{magic: "wOF2", fileType: fileType{"font/woff2", "WOFF2 font"}},
+	{magic: "OTTO", fileType: fileType{"font/otf", "OpenType font"}},
+	{magic: "\x00\x01\x00\x00\x00", fileType: fileType{"font/ttf", "TrueType font"}},
+}
+
+// detectType identifies the format of content from its head. text reports
+// whether the head sniffed as text.
+func detectType(head []byte, text bool) fileType {
+	if ft, _, ok := matchSignature(head, text); ok {
+		return ft
+	}
+	if text {
+		return plainText
+	}
+	return unknownData
+}
+
+// matchSignature returns the first signature matching head. With text set,
+// only signatures that may appear in text are tried. weak reports a magic
+// number of at most four printable characters, such as "BM" or "MZ", which
+// may just as well start a line of text.
+func matchSignature(head []byte, text bool) (ft fileType, weak bool, ok bool) {
+	for _, s := range signatures {
+		if text && !s.text {
+			continue
+		}
+		end := s.offset + len(s.magic)
+		if end > len(head) || string(head[s.offset:end]) != s.magic {
+			continue
+		}
+		ft := s.fileType
+		if s.refine != nil {
+			var ok bool
+			if ft, ok = s.refine(head, ft); !ok {
+				continue
+			}
+		}
+		return ft, s.offset == 0 && len(s.magic) <= 4 && printable(s.magic), true
+	}
+	return fileType{}, false, false
+}
+
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func refineRIFF(head []byte, ft fileType) (fileType, bool) {
+	if len(head) < 12 {
+		return ft, false
+	}
+	switch string(head[8:12]) {
+	case "WEBP":
+		return fileType{"image/webp", "WebP image"}, true
+	case "WAVE":
+		return fileType{"audio/wav", "WAVE audio"}, true
+	case "AVI ":
+		return fileType{"video/x-msvideo", "AVI video"}, true
+	}
+	return fileType{"application/octet-stream", "RIFF data"}, true
+}
+
+// refineZip recognizes zip-based formats by the name of the first entry.
+func refineZip(head []byte, ft fileType) (fileType, bool) {
+	if len(head) < 30 {
+		return ft, true
+	}
+	name := head[30:]
+	switch {
+	case bytes.HasPrefix(name, []byte("mimetypeapplication/epub+zip")):
+		return fileType{"application/epub+zip", "EPUB document"}, true
+	case bytes.HasPrefix(name, []byte("META-INF/")):
+		return fileType{"application/java-archive", "Java archive"}, true
+	}
+	return ft, true
+}
+
+func refineELF(head []byte, ft fileType) (fileType, bool) {
+	if len(head) < 18 {
+		return ft, true
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if head[5] == 2 {
+		order = binary.BigEndian
+	}
+	switch order.Uint16(head[16:]) {
+	case 1:
+		return fileType{"application/x-object", "ELF relocatable"}, true
+	case 3:
+		// Position-independent executables are shared objects that name
+		// an interpreter.
+		if elfInterp(head, order) {
+			return fileType{"application/x-pie-executable", "ELF pie executable"}, true
+		}
+		return fileType{"application/x-sharedlib", "ELF shared object"}, true
+	case 4:
+		return fileType{"application/x-coredump", "ELF core file"}, true
+	}
+	return ft, true
+}
+
+// elfInterp reports whether the program headers, if they fit in head,
+// include PT_INTERP.
+func elfInterp(head []byte, order binary.ByteOrder) bool {
+	var off, size, num int
+	switch head[4] {
+	case 1: // 32-bit
+		if len(head) < 0x34 {
+			return false
+		}
+		off = int(order.Uint32(head[0x1c:]))
+		size, num = int(order.Uint16(head[0x2a:])), int(order.Uint16(head[0x2c:]))
+	case 2: // 64-bit
+		if len(head) < 0x40 {
+			return false
+		}
+		off = int(min(order.Uint64(head[0x20:]), uint64(len(head))))
+		size, num = int(order.Uint16(head[0x36:])), int(order.Uint16(head[0x38:]))
+	default:
+		return false
+	}
+	for i := range num {
+		p := off + i*size
+		if size < 4 || p < 0 || p+4 > len(head) {
+			return false
+		}
+		if order.Uint32(head[p:]) == 3 {
+			return true
+		}
+	}
+	return false
+}
+
+// refineCafebabe tells Mach-O universal binaries from Java class files,
+// which share a magic number: the next word is a small architecture count
+// in the former and a class file version (45 or more) in the latter.
+func refineCafebabe(head []byte, ft fileType) (fileType, bool) {
+	if len(head) < 8 {
+		return ft, false
+	}
+	if binary.BigEndian.Uint32(head[4:]) < 45 {
+		return fileType{"application/x-mach-binary", "Mach-O universal binary"}, true
+	}
+	return fileType{"application/java-vm", "Java class file"}, true
+}
+
+// refinePE follows the DOS header to a PE header, if there is one.
+func refinePE(head []byte, ft fileType) (fileType, bool) {
+	if len(head) < 0x40 {
+		return ft, true
+	}
+	off := int(binary.LittleEndian.Uint32(head[0x3c:]))
+	if off < 0x40 || off+24 > len(head) || string(head[off:off+4]) != "PE\x00\x00" {
+		return ft, true
+	}
+	// IMAGE_FILE_DLL in the COFF characteristics.
+	if binary.LittleEndian.Uint16(head[off+22:])&0x2000 != 0 {
+		return fileType{"application/vnd.microsoft.portable-executable", "PE DLL"}, true
+	}
+	return fileType{"application/vnd.microsoft.portable-executable", "PE executable"}, true
+}
+
+func refineFtyp(head []byte, ft fileType) (fileType, bool) {
+	if len(head) < 12 {
+		return ft, true
+	}
+	switch string(head[8:12]) {
+	case "qt  ":
+		return fileType{"video/quicktime", "QuickTime movie"}, true
+	case "heic", "heix", "mif1":
+		return fileType{"image/heic", "HEIF image"}, true
+	case "avif":
+		return fileType{"image/avif", "AVIF image"}, true
+	case "M4A ":
+		return fileType{"audio/mp4", "MPEG-4 audio"}, true
+	}
+	return ft, true
+}
@@ -0,0 +1,76 @@
+package analyze
+
+import "testing"
+
+// header returns size zero bytes with the given strings written at the
+// given offsets: header(64, 0, "MZ", 0x3c, "\x40").
+func header(size int, at ...any) []byte {
+	b := make([]byte, size)
+	for i := 0; i+1 < len(at); i += 2 {
+		copy(b[at[i].(int):], at[i+1].(string))
+	}
+	return b
+}
+
+func TestDetectType(t *testing.T) {
+	// A 64-bit little-endian ELF file of type e_type with one program header
+	// of type phType.
+	elf := func(eType, phType string) []byte {
+		return header(0x80, 0, "\x7fELF\x02\x01\x01", 16, eType,
+			0x20, "\x40", 0x36, "\x38", 0x38, "\x01", 0x40, phType)
+	}
+	tests := []struct {
+		name string
+		head []byte
+		text bool
+		mime string
+		typ  string
+	}{
+		{"png", header(16, 0, "\x89PNG\r\n\x1a\n"), false, "image/png", "PNG image"},
+		{"jpeg", header(16, 0, "\xff\xd8\xff\xe0"), false, "image/jpeg", "JPEG image"},
+		{"gif", []byte("GIF89a\x01\x00"), false, "image/gif", "GIF image"},
+		{"webp", header(16, 0, "RIFF", 8, "WEBP"), false, "image/webp", "WebP image"},
+		{"wave", header(16, 0, "RIFF", 8, "WAVE"), false, "audio/wav", "WAVE audio"},
+		{"other riff", header(16, 0, "RIFF", 8, "XYZW"), false, "application/octet-stream", "RIFF data"},
+		{"short riff", []byte("RIFF\x00\x00"), false, "application/octet-stream", "data"},
+		{"tiff", header(8, 0, "II*\x00"), false, "image/tiff", "TIFF image"},
+
+		{"zip", header(40, 0, "PK\x03\x04", 30, "a.txt"), false, "application/zip", "Zip archive"},
+		{"jar", header(48, 0, "PK\x03\x04", 30, "META-INF/MANIFEST.MF"), false, "application/java-archive", "Java archive"},
+		{"epub", header(64, 0, "PK\x03\x04", 30, "mimetypeapplication/epub+zip"), false, "application/epub+zip", "EPUB document"},
+		{"gzip", header(10, 0, "\x1f\x8b\x08"), false, "application/gzip", "gzip compressed data"},
+		{"tar", header(512, 0, "a.txt", 257, "ustar\x0000"), false, "application/x-tar", "tar archive"},
+		{"deb", header(16, 0, "!<arch>\ndebian"), false, "application/vnd.debian.binary-package", "Debian package"},
+		{"ar", header(16, 0, "!<arch>\nfoo.o/"), false, "application/x-archive", "ar archive"},
+
+		{"elf exec", elf("\x02", "\x01"), false, "application/x-executable", "ELF executable"},
+		{"elf object", elf("\x01", "\x01"), false, "application/x-object", "ELF relocatable"},
+		{"elf shared", elf("\x03", "\x01"), false, "application/x-sharedlib", "ELF shared object"},
+		{"elf pie", elf("\x03", "\x03"), false, "application/x-pie-executable", "ELF pie executable"},
+		{"elf core", elf("\x04", "\x01"), false, "application/x-coredump", "ELF core file"},
+		{"mach-o universal", header(8, 0, "\xca\xfe\xba\xbe\x00\x00\x00\x02"), false, "application/x-mach-binary", "Mach-O universal binary"},
+		{"java class", header(8, 0, "\xca\xfe\xba\xbe\x00\x00\x00\x34"), false, "application/java-vm", "Java class file"},
+		{"dos", header(64, 0, "MZ"), false, "application/x-dosexec", "DOS executable"},
+		{"pe", header(0x100, 0, "MZ", 0x3c, "\x80", 0x80, "PE\x00\x00"), false, "application/vnd.microsoft.portable-executable", "PE executable"},
+		{"pe dll", header(0x100, 0, "MZ", 0x3c, "\x80", 0x80, "PE\x00\x00", 0x80+23, "\x20"), false, "application/vnd.microsoft.portable-executable", "PE DLL"},
+		{"wasm", header(8, 0, "\x00asm\x01"), false, "application/wasm", "WebAssembly binary"},
+
+		{"mp4", header(16, 4, "ftypisom"), false, "video/mp4", "ISO media"},
+		{"quicktime", header(16, 4, "ftypqt  "), false, "video/quicktime", "QuickTime movie"},
+		{"heic", header(16, 4, "ftypheic"), false, "image/heic", "HEIF image"},
+		{"sqlite", header(32, 0, "SQLite format 3\x00"), false, "application/vnd.sqlite3", "SQLite database"},
+
+		// Text only matches the signatures of text formats.
+		{"pdf", []byte("%PDF-1.7\n"), true, "application/pdf", "PDF document"},
+		{"rtf", []byte("{\\rtf1\\ansi"), true, "application/rtf", "RTF document"},
+		{"plain", []byte("BMW owners\n"), true, "text/plain", "text"},
+		{"unknown", []byte{0x00, 0x01, 0x02, 0x03}, false, "application/octet-stream", "data"},
+		{"empty", nil, true, "text/plain", "text"},
+	}
+	for _, tt := range tests {
+		ft := detectType(tt.head, tt.text)
+		if ft.mime != tt.mime || ft.typ != tt.typ {
+			t.Errorf("%s: detectType = %q, %q, want %q, %q", tt.name, ft.mime, ft.typ, tt.mime, tt.typ)
+		}
+	}
+}
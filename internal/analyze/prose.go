@@ -0,0 +1,215 @@
+package analyze
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wordsPerMinute is the silent reading speed used for reading time.
+const wordsPerMinute = 238
+
+// proseExts get prose metrics without Options.Prose.
+var proseExts = map[string]bool{".md": true, ".txt": true}
+
+// proseStats counts the sentences, paragraphs and syllables behind the
+// readability scores. Words here contain at least one letter, so numbers
+// and punctuation runs are left out.
+type proseStats struct {
+	// markdown skips fenced code blocks.
+	markdown bool
+	// head holds the start of the current line until it can be told from a
+	// code fence; decided is set once it has been, and skip drops the rest
+	// of the line.
+	head          []rune
+	decided, skip bool
+	fenced        bool
+
+	words, sentences, paragraphs, syllables int
+	// pending counts the words of the sentence in progress.
+	pending int
+	// word holds the lowercased letters of the current word.
+	word      []rune
+	inWord    bool
+	hasLetter bool
+	// terminal is set after sentence punctuation, which ends the sentence
+	// once whitespace follows.
+	terminal bool
+	lineText bool
+	inPara   bool
+	// prevCR is set after a '\r', so that a '\n' right after it does not
+	// end another line.
+	prevCR bool
+}
+
+func newProseStats(markdown bool) *proseStats {
+	return &proseStats{markdown: markdown}
+}
+
+func (p *proseStats) add(r rune) {
+	crlf := p.prevCR && r == '\n'
+	p.prevCR = r == '\r'
+	switch {
+	case crlf:
+	case r == '\n', r == '\r':
+		p.endLine()
+	case p.decided:
+		if !p.skip {
+			p.feed(r)
+		}
+	default:
+		p.head = append(p.head, r)
+		// Up to three spaces and three fence characters.
+		if len(p.head) == 6 {
+			p.decide()
+		}
+	}
+}
+
+// decide settles whether the buffered line start opens or closes a code
+// fence, or lies inside one, and otherwise replays it.
+func (p *proseStats) decide() {
+	p.decided = true
+	if p.markdown {
+		s := strings.TrimLeft(string(p.head), " ")
+		if len(p.head)-len([]rune(s)) <= 3 && (strings.HasPrefix(s, "```") || strings.HasPrefix(s, "~~~")) {
+			p.fenced = !p.fenced
+			p.skip = true
+		} else {
+			p.skip = p.fenced
+		}
+	}
+	if !p.skip {
+		for _, r := range p.head {
+			p.feed(r)
+		}
+	}
+	p.head = p.head[:0]
+}
+
+func (p *proseStats) endLine() {
+	if !p.decided {
+		p.decide()
+	}
+	p.feed(' ')
+	// A blank line, or a code block, ends the paragraph and any sentence
+	// left without punctuation, such as a heading.
+	if !p.lineText {
+		p.inPara = false
+		p.endSentence()
+	}
+	p.decided, p.skip, p.lineText = false, false, false
+}
+
+func (p *proseStats) feed(r rune) {
+	if !unicode.IsSpace(r) {
+		p.lineText = true
+		if !p.inPara {
+			p.inPara = true
+			p.paragraphs++
+		}
+	}
+	switch {
+	case unicode.IsLetter(r), unicode.IsDigit(r), unicode.In(r, unicode.Mn), r == '\'', r == '’':
+		if p.terminal {
+			// "e.g" or "3.14": the punctuation did not end a sentence.
+			p.terminal = false
+		}
+		if !p.inWord {
+			p.inWord, p.hasLetter = true, false
+			p.word = p.word[:0]
+		}
+		if unicode.IsLetter(r) {
+			p.hasLetter = true
+			if len(p.word) < 64 {
+				p.word = append(p.word, unicode.ToLower(r))
+			}
+		}
+	case r == '.', r == '!', r == '?', r == '…', r == '。', r == '！', r == '？':
+		// A period after a single letter is taken for an initial or an
+		// abbreviation such as "e.g.".
+		abbrev := r == '.' && p.inWord && len(p.word) == 1
+		p.endWord()
+		if p.pending > 0 && !abbrev {
+			p.terminal = true
+		}
+	case unicode.IsSpace(r):
+		p.endWord()
+		if p.terminal {
+			p.endSentence()
+		}
+	default:
+		// Closing quotes and brackets after the punctuation keep it terminal.
+		p.endWord()
+	}
+}
+
+func (p *proseStats) endWord() {
+	if !p.inWord {
+		return
+	}
+	p.inWord = false
+	if p.hasLetter {
+		p.words++
+		p.pending++
+		p.syllables += syllables(p.word)
+	}
+}
+
+func (p *proseStats) endSentence() {
+	if p.pending > 0 {
+		p.sentences++
+	}
+	p.pending, p.terminal = 0, false
+}
+
+func (p *proseStats) finish() {
+	p.endLine()
+	p.endSentence()
+}
+
+// scores returns the Flesch Reading Ease and Flesch-Kincaid grade level,
+// both 0 without words.
+func (p *proseStats) scores() (ease, grade float64) {
+	if p.words == 0 {
+		return 0, 0
+	}
+	wps := float64(p.words) / float64(p.sentences)
+	spw := float64(p.syllables) / float64(p.words)
+	return 206.835 - 1.015*wps - 84.6*spw, 0.39*wps + 11.8*spw - 15.59
+}
+
+func (p *proseStats) readingMinutes() float64 {
+	return float64(p.words) / wordsPerMinute
+}
+
+// syllables estimates the syllables of an English word from its vowel
+// groups, dropping a silent final e and the e of a final -ed or -es where
+// it is usually not pronounced. Every word has at least one.
+func syllables(w []rune) int {
+	n, prev := 0, false
+	for _, r := range w {
+		v := isVowel(r)
+		if v && !prev {
+			n++
+		}
+		prev = v
+	}
+	if l := len(w); n > 1 && l > 2 {
+		switch {
+		case w[l-1] == 'e' && !(w[l-2] == 'l' && !isVowel(w[l-3])):
+			// make, but not table
+			n--
+		case w[l-1] == 'd' && w[l-2] == 'e' && !strings.ContainsRune("tdaeiouy", w[l-3]):
+			// jumped, but not wanted
+			n--
+		case w[l-1] == 's' && w[l-2] == 'e' && !strings.ContainsRune("sxzcgaeiouy", w[l-3]):
+			// makes, but not boxes
+			n--
+		}
+	}
+	return max(n, 1)
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouyàáâäæèéêëìíîïòóôöøùúûü", r)
+}
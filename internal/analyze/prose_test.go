@@ -0,0 +1,114 @@
+package analyze
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{"a", 1},
+		{"the", 1},
+		{"be", 1},
+		{"rhythm", 1},
+		{"queue", 1},
+		{"make", 1},
+		{"table", 2},
+		{"syllable", 3},
+		{"jumped", 1},
+		{"wanted", 2},
+		{"makes", 1},
+		{"boxes", 2},
+		{"readability", 5},
+		{"caf\u00e9", 2},
+		{"nth", 1},
+	}
+	for _, tt := range tests {
+		if got := syllables([]rune(tt.word)); got != tt.want {
+			t.Errorf("syllables(%q) = %d, want %d", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestProseStats(t *testing.T) {
+	tests := []struct {
+		name                         string
+		in                           string
+		markdown                     bool
+		words, sentences, paragraphs int
+	}{
+		{"empty", "", false, 0, 0, 0},
+		{"two sentences", "Hello world. This is a test.\n", false, 6, 2, 1},
+		{"no final newline", "Hello world", false, 2, 1, 1},
+		{"paragraphs", "One.\n\nTwo.\n", false, 2, 2, 2},
+		{"heading", "# Heading\n\nText here.\n", true, 3, 2, 2},
+		{"wrapped sentence", "A long\nsentence.\n", false, 3, 1, 1},
+		{"question and exclamation", "Why? Because! Fine.\n", false, 3, 3, 1},
+		{"abbreviation", "See e.g. this one. Right?\n", false, 6, 2, 1},
+		{"decimal", "Pi is 3.14 today.\n", false, 3, 1, 1},
+		{"closing quote", "\"Wait!\" he said.\n", false, 3, 2, 1},
+		{"fence", "Text.\n```\ncode here.\n```\nMore.\n", true, 2, 2, 2},
+		{"tilde fence", "Text.\n  ~~~\ncode.\n  ~~~\n", true, 1, 1, 1},
+		{"indented fence", "a\n    ```\nb.\n", true, 2, 1, 1},
+		{"fence in plain text", "Text.\n```\ncode here.\n```\nMore.\n", false, 4, 3, 1},
+		{"unclosed fence", "Text.\n```\ncode here.\n", true, 1, 1, 1},
+	}
+	for _, tt := range tests {
+		p := newProseStats(tt.markdown)
+		for _, r := range tt.in {
+			p.add(r)
+		}
+		p.finish()
+		if p.words != tt.words || p.sentences != tt.sentences || p.paragraphs != tt.paragraphs {
+			t.Errorf("%s: words=%d sentences=%d paragraphs=%d, want %d %d %d",
+				tt.name, p.words, p.sentences, p.paragraphs, tt.words, tt.sentences, tt.paragraphs)
+		}
+	}
+}
+
+func TestProseScores(t *testing.T) {
+	tests := []struct {
+		words, sentences, syllables int
+		ease, grade, minutes        float64
+	}{
+		{0, 0, 0, 0, 0, 0},
+		{100, 5, 150, 59.635, 9.91, 100.0 / 238},
+		{476, 476, 476, 121.22, -3.4, 2},
+	}
+	for _, tt := range tests {
+		p := &proseStats{words: tt.words, sentences: tt.sentences, syllables: tt.syllables}
+		ease, grade := p.scores()
+		if math.Abs(ease-tt.ease) > 1e-9 || math.Abs(grade-tt.grade) > 1e-9 || p.readingMinutes() != tt.minutes {
+			t.Errorf("%d words, %d sentences, %d syllables: ease=%v grade=%v minutes=%v, want %v %v %v",
+				tt.words, tt.sentences, tt.syllables, ease, grade, p.readingMinutes(), tt.ease, tt.grade, tt.minutes)
+		}
+	}
+}
+
+func TestAnalyzeReaderProse(t *testing.T) {
+	tests := []struct {
+		name  string
+		prose bool
+		want  bool
+	}{
+		{"notes.md", false, true},
+		{"NOTES.TXT", false, true},
+		{"main.go", false, false},
+		{"main.go", true, true},
+	}
+	for _, tt := range tests {
+		st, err := AnalyzeReader(context.Background(), strings.NewReader("Some text.\n"), tt.name, Options{Prose: tt.prose})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Prose != tt.want || tt.want && (st.Sentences != 1 || st.Paragraphs != 1 || st.Syllables != 2) {
+			t.Errorf("%s with prose=%v: got %v, %d sentences, %d paragraphs, %d syllables",
+				tt.name, tt.prose, st.Prose, st.Sentences, st.Paragraphs, st.Syllables)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package analyze
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ADJB1212/Aperio/internal/lang"
+)
+
+// slocCounter classifies each line as code, comment or blank, carrying block
+// comment state across lines.
+type slocCounter struct {
+	lang *lang.Language
+	line []byte
+
+	// depth is the block comment nesting level; block indexes the open
+	// delimiter pair in lang.BlockComments.
+	depth int
+	block int
+	// prevCR is set after a '\r', so that a '\n' right after it does not
+	// end another line.
+	prevCR bool
+
+	code, comment, blank int
+}
+
+func newSLOC(l *lang.Language) *slocCounter {
+	if l == nil {
+		return nil
+	}
+	return &slocCounter{lang: l}
+}
+
+func (s *slocCounter) add(r rune) {
+	crlf := s.prevCR && r == '\n'
+	s.prevCR = r == '\r'
+	switch {
+	case crlf:
+	case r == '\n', r == '\r':
+		s.classify()
+		s.line = s.line[:0]
+	default:
+		s.line = utf8.AppendRune(s.line, r)
+	}
+}
+
+// finish classifies a final line that lacks a trailing newline.
+func (s *slocCounter) finish() {
+	if len(s.line) > 0 {
+		s.classify()
+	}
+}
+
+func (s *slocCounter) classify() {
+	l, line := s.lang, s.line
+	hasCode, hasComment := false, false
+	var quote byte
+
+	for i := 0; i < len(line); {
+		b := line[i]
+		switch {
+		case s.depth > 0:
+			blk := l.BlockComments[s.block]
+			switch {
+			case l.Nested && bytes.HasPrefix(line[i:], []byte(blk.Start)):
+				s.depth++
+				i += len(blk.Start)
+			case bytes.HasPrefix(line[i:], []byte(blk.End)):
+				s.depth--
+				i += len(blk.End)
+			default:
+				i++
+			}
+			if !isBlank(b) {
+				hasComment = true
+			}
+		case quote != 0:
+			if b == '\\' {
+				i += 2
+				continue
+			}
+			if b == quote {
+				quote = 0
+			}
+			i++
+		case isBlank(b):
+			i++
+		default:
+			if idx, ok := blockStart(l, line[i:]); ok {
+				s.depth, s.block = 1, idx
+				i += len(l.BlockComments[idx].Start)
+				hasComment = true
+				continue
+			}
+			if lineComment(l, line[i:]) {
+				hasComment = true
+				i = len(line)
+				continue
+			}
+			if strings.IndexByte(l.Quotes, b) >= 0 {
+				quote = b
+			}
+			hasCode = true
+			i++
+		}
+	}
+
+	switch {
+	case hasCode:
+		s.code++
+	case hasComment:
+		s.comment++
+	default:
+		s.blank++
+	}
+}
+
+// blockStart reports which block comment, if any, opens at the start of p.
+func blockStart(l *lang.Language, p []byte) (int, bool) {
+	for i, blk := range l.BlockComments {
+		if bytes.HasPrefix(p, []byte(blk.Start)) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func lineComment(l *lang.Language, p []byte) bool {
+	for _, lc := range l.LineComments {
+		if bytes.HasPrefix(p, []byte(lc)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\f' || b == '\v'
+}
@@ -5,17 +5,25 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"iter"
 	"os"
 	"runtime"
 	"strings"
+	"time"
+
+	"github.com/ADJB1212/Aperio/internal/expr"
+	"github.com/ADJB1212/Aperio/internal/glob"
+	"github.com/ADJB1212/Aperio/internal/util"
 )
 
 // Config captures all command-line options and resolved inputs for aperio.
 type Config struct {
 	ShowSum     bool
 	ShowVersion bool
-	SortBy      string
-	Desc        bool
+	Sort        []SortKey
+	Natural     bool
+	Ungrouped   bool
 	Format      string
 	NoHeader    bool
 	Plain       bool
@@ -23,7 +31,47 @@ type Config struct {
 	Jobs        int
 	Progress    bool
 	Commas      bool
-	Files       []string
+	Recursive   bool
+	MaxDepth    int
+	Follow      bool
+	OneFS       bool
+	NoIgnore    bool
+	Hidden      bool
+	Include     []string
+	Exclude     []string
+	MinSize     int64
+	MaxSize     int64
+	NewerThan   time.Time
+	OlderThan   time.Time
+	Kind        string
+	PathStyle   string
+	RelativeTo  string
+	GroupBy     []string
+	Stream      bool
+	Columns     []string
+	Where       *expr.Program
+	Top         int
+	Hash        string
+	Duplicates  bool
+	// CharMode is what Chars counts: runes, graphemes or bytes.
+	CharMode string
+	// Prose computes prose metrics for every text file, not only .md and
+	// .txt files.
+	Prose bool
+	// CheckHygiene and MaxLineLength select checks that replace the usual
+	// rows: mixed line endings and missing final newlines, and lines longer
+	// than MaxLineLength runes.
+	CheckHygiene  bool
+	MaxLineLength int
+	// Template, TemplateHeader and TemplateFooter hold text/template source
+	// for --format template; Template is run once per file.
+	Template       string
+	TemplateHeader string
+	TemplateFooter string
+	Files          []string
+	// Stdin is set instead of Files when paths are piped in; they are read
+	// lazily as the run consumes them.
+	Stdin *PathReader
 }
 
 // Usage returns a concise usage string suitable for errors/help.
@@ -34,13 +82,72 @@ func Usage() string {
 
 var (
 	validSortBy = map[string]struct{}{
-		"name": {}, "ext": {}, "size": {}, "lines": {}, "words": {}, "chars": {}, "modified": {},
+		"name": {}, "path": {}, "ext": {}, "language": {}, "kind": {}, "mime": {}, "type": {}, "encoding": {}, "size": {}, "lines": {},
+		"code": {}, "comment": {}, "blank": {}, "words": {}, "chars": {}, "modified": {},
 	}
 	validFormat = map[string]struct{}{
-		"table": {}, "csv": {}, "json": {},
+		"table": {}, "csv": {}, "json": {}, "ndjson": {}, "markdown": {}, "html": {},
+	}
+	validKind = map[string]struct{}{
+		"text": {}, "binary": {},
+	}
+	validGroupBy = map[string]struct{}{
+		"ext": {}, "language": {}, "dir": {}, "topdir": {}, "kind": {}, "mime": {}, "type": {}, "encoding": {}, "eol": {}, "indent": {},
+	}
+	validColumns = map[string]struct{}{
+		"name": {}, "path": {}, "ext": {}, "kind": {}, "mime": {}, "type": {}, "encoding": {}, "language": {}, "bytes": {}, "size": {}, "lines": {},
+		"code": {}, "comment": {}, "blank": {}, "words": {}, "chars": {}, "eol": {}, "lf": {}, "crlf": {}, "cr": {},
+		"bom": {}, "final_newline": {}, "trailing_space": {}, "line_max": {}, "line_mean": {}, "line_p95": {},
+		"width_max": {}, "width_mean": {}, "width_p95": {}, "longest_line": {}, "indent": {}, "indent_width": {},
+		"mixed_indent": {}, "indent_max": {}, "indent_mean": {}, "prose": {}, "sentences": {}, "paragraphs": {},
+		"syllables": {}, "reading_ease": {}, "grade_level": {}, "reading_time": {}, "modified": {}, "error": {}, "hash": {},
+	}
+	// whereFields are the --where fields: the column keys, with size in bytes.
+	whereFields = map[string]expr.Type{
+		"name": expr.String, "path": expr.String, "ext": expr.String, "kind": expr.String,
+		"mime": expr.String, "type": expr.String, "encoding": expr.String,
+		"language": expr.String, "bytes": expr.Number, "size": expr.Number, "lines": expr.Number,
+		"code": expr.Number, "comment": expr.Number, "blank": expr.Number, "words": expr.Number,
+		"chars": expr.Number, "modified": expr.Time, "error": expr.String, "hash": expr.String,
+		"eol": expr.String, "lf": expr.Number, "crlf": expr.Number, "cr": expr.Number, "bom": expr.Bool,
+		"final_newline": expr.Bool, "trailing_space": expr.Number, "line_max": expr.Number, "line_mean": expr.Number,
+		"line_p95": expr.Number, "width_max": expr.Number, "width_mean": expr.Number, "width_p95": expr.Number,
+		"longest_line": expr.Number, "indent": expr.String, "indent_width": expr.Number, "mixed_indent": expr.Number,
+		"indent_max": expr.Number, "indent_mean": expr.Number, "prose": expr.Bool, "sentences": expr.Number,
+		"paragraphs": expr.Number, "syllables": expr.Number, "reading_ease": expr.Number, "grade_level": expr.Number,
+		"reading_time": expr.Number,
+	}
+	validCharMode = map[string]struct{}{
+		"runes": {}, "graphemes": {}, "bytes": {},
+	}
+	validHash = map[string]struct{}{
+		"sha256": {}, "sha1": {}, "md5": {}, "crc32": {},
+	}
+	validPathStyle = map[string]struct{}{
+		"base": {}, "relative": {}, "absolute": {},
 	}
 )
 
+// stringList is a repeatable flag that also splits comma-separated values.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// SortKey is one --sort key and its direction.
+type SortKey struct {
+	Key  string
+	Desc bool
+}
+
 // UsageError indicates improper CLI usage or invalid flag values.
 type UsageError struct {
 	Msg string
@@ -57,34 +164,83 @@ func ParseArgs(args []string, stdin *os.File) (Config, error) {
 	var cfg Config
 
 	// Defaults
-	cfg.SortBy = "name"
 	cfg.Format = "table"
 	cfg.Jobs = defaultJobs()
 
+	var include, exclude, groupBy, columns stringList
+	sortBy := stringList{"name"}
+	sortSet := false
+	var desc bool
+	var minSize, maxSize, newerThan, olderThan string
+	var tmpl, tmplFile string
+	var where string
+
 	fs := flag.NewFlagSet("aperio", flag.ContinueOnError)
 	fs.SetOutput(new(strings.Builder)) // suppress default printing; caller formats errors
 
 	// Primary flags
 	fs.BoolVar(&cfg.ShowSum, "sum", false, "Show totals for size, lines, words, and chars")
 	fs.BoolVar(&cfg.ShowVersion, "version", false, "Print version and exit")
-	fs.StringVar(&cfg.SortBy, "sort", cfg.SortBy, "Sort by: name, ext, size, lines, words, chars, modified")
-	fs.BoolVar(&cfg.Desc, "desc", false, "Sort descending")
-	fs.StringVar(&cfg.Format, "format", cfg.Format, "Output format: table, csv, json")
+	fs.Func("sort", "Sort keys, comma-separated, each optionally prefixed with - for descending (e.g. ext,-lines,name): name, path, ext, language, kind, mime, type, encoding, size, lines, code, comment, blank, words, chars, modified", func(v string) error {
+		if !sortSet {
+			sortBy, sortSet = nil, true
+		}
+		return sortBy.Set(v)
+	})
+	fs.BoolVar(&desc, "desc", false, "Reverse the direction of every sort key")
+	fs.BoolVar(&cfg.Natural, "natural", false, "Compare names in natural order (file2 before file10)")
+	fs.BoolVar(&cfg.Ungrouped, "ungrouped", false, "Sort purely by the keys, without putting binary files after text and errors last")
+	fs.StringVar(&cfg.Format, "format", cfg.Format, "Output format: table, csv, json, ndjson, markdown, html")
 	fs.BoolVar(&cfg.NoHeader, "no-header", false, "Omit header row in CSV output")
 	fs.BoolVar(&cfg.Plain, "plain", false, "Use plain ASCII table borders")
 	fs.IntVar(&cfg.Jobs, "jobs", cfg.Jobs, "Maximum concurrent file analyses")
 	fs.BoolVar(&cfg.Progress, "progress", false, "Show progress bar on stderr")
 	fs.BoolVar(&cfg.Commas, "commas", false, "Format counts (lines, words, chars) with commas")
 	fs.BoolVar(&cfg.NoIcons, "no-icons", false, "Disable Nerd Fonts icons in table output")
+	fs.StringVar(&cfg.PathStyle, "path-style", "", "File column shows: base, relative, absolute (default: base)")
+	fs.BoolVar(&cfg.Stream, "stream", false, "Write unsorted CSV/JSON rows as files finish (flat memory)")
+	fs.StringVar(&tmpl, "template", "", "Go template run for each file, e.g. '{{.Path}}\\t{{.Lines}}\\n'")
+	fs.StringVar(&tmplFile, "template-file", "", "Read the per-file template from a file")
+	fs.StringVar(&cfg.TemplateHeader, "template-header", "", "Template printed once before the rows; receives the totals")
+	fs.StringVar(&cfg.TemplateFooter, "template-footer", "", "Template printed once after the rows; receives the totals")
+	fs.StringVar(&where, "where", "", "Only keep files matching an expression, e.g. 'lines > 500 && ext in [\".go\"]'")
+	fs.IntVar(&cfg.Top, "top", 0, "Show only the first N rows after sorting and fold the rest into one \"others\" row")
+	fs.StringVar(&cfg.Hash, "hash", "", "Also compute a content hash while reading: sha256, sha1, md5, crc32")
+	fs.StringVar(&cfg.CharMode, "char-mode", "runes", "What Chars counts: runes, graphemes (user-perceived characters), bytes")
+	fs.BoolVar(&cfg.Prose, "prose", false, "Count sentences, paragraphs and syllables and score readability for every text file (always on for .md and .txt)")
+	fs.BoolVar(&cfg.Duplicates, "duplicates", false, "Report groups of files with identical content and the bytes they waste")
+	fs.BoolVar(&cfg.CheckHygiene, "check-hygiene", false, "Report files with mixed line endings or no final newline and exit 3 if there are any")
+	fs.IntVar(&cfg.MaxLineLength, "max-line-length", 0, "Report path:line of lines longer than N characters and exit 3 if there are any")
+	fs.Var(&columns, "columns", "Per-file columns to show, in order (e.g. name,size,lines; comma-separated)")
+	fs.Var(&groupBy, "group-by", "Aggregate rows by: ext, language, dir, topdir, kind, mime, type, encoding, eol, indent (comma-separated)")
+	fs.StringVar(&cfg.RelativeTo, "relative-to", "", "Make paths relative to this directory (implies --path-style relative)")
+	fs.BoolVar(&cfg.Recursive, "recursive", false, "Descend into subdirectories of directory arguments")
+	fs.IntVar(&cfg.MaxDepth, "max-depth", 0, "Maximum directory depth to descend (implies --recursive)")
+	fs.BoolVar(&cfg.Follow, "follow", false, "Follow symlinked directories while walking")
+	fs.BoolVar(&cfg.OneFS, "one-file-system", false, "Do not cross filesystem boundaries while walking")
+	fs.BoolVar(&cfg.NoIgnore, "no-ignore", false, "Do not honor .gitignore, .ignore or .aperioignore files")
+	fs.BoolVar(&cfg.Hidden, "hidden", false, "Include hidden files and directories while walking")
+	fs.Var(&include, "include", "Only analyze files matching this glob (repeatable, supports **)")
+	fs.Var(&exclude, "exclude", "Skip files and directories matching this glob (repeatable, supports **)")
+	fs.StringVar(&minSize, "min-size", "", "Only analyze files at least this large (e.g. 10k, 1MiB)")
+	fs.StringVar(&maxSize, "max-size", "", "Only analyze files at most this large (e.g. 10k, 1MiB)")
+	fs.StringVar(&newerThan, "newer-than", "", "Only analyze files modified after a date or age (e.g. 2026-01-01, 7d)")
+	fs.StringVar(&olderThan, "older-than", "", "Only analyze files modified before a date or age (e.g. 2026-01-01, 7d)")
+	fs.StringVar(&cfg.Kind, "kind", "", "Only analyze files of this kind: text, binary")
 
 	// Aliases
 	fs.BoolVar(&cfg.ShowSum, "s", cfg.ShowSum, "Alias for --sum")
 	fs.BoolVar(&cfg.ShowVersion, "v", cfg.ShowVersion, "Alias for --version")
-	fs.BoolVar(&cfg.Desc, "r", cfg.Desc, "Alias for --desc")
+	fs.BoolVar(&desc, "r", desc, "Alias for --desc")
 	fs.StringVar(&cfg.Format, "f", cfg.Format, "Alias for --format")
 	fs.IntVar(&cfg.Jobs, "j", cfg.Jobs, "Alias for --jobs")
 	fs.BoolVar(&cfg.Progress, "p", cfg.Progress, "Alias for --progress")
 	fs.BoolVar(&cfg.Commas, "c", cfg.Commas, "Alias for --commas")
+	fs.BoolVar(&cfg.Recursive, "R", cfg.Recursive, "Alias for --recursive")
+	fs.BoolVar(&cfg.Follow, "L", cfg.Follow, "Alias for --follow")
+	fs.BoolVar(&cfg.OneFS, "x", cfg.OneFS, "Alias for --one-file-system")
+	fs.BoolVar(&cfg.NoIgnore, "I", cfg.NoIgnore, "Alias for --no-ignore")
+	fs.BoolVar(&cfg.Hidden, "H", cfg.Hidden, "Alias for --hidden")
 
 	if err := fs.Parse(args); err != nil {
 		return Config{}, &UsageError{Msg: Usage()}
@@ -96,9 +252,19 @@ func ParseArgs(args []string, stdin *os.File) (Config, error) {
 	}
 
 	// Normalize and validate
-	cfg.SortBy = strings.ToLower(cfg.SortBy)
-	if _, ok := validSortBy[cfg.SortBy]; !ok {
-		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --sort value: %q\n\n%s", cfg.SortBy, Usage())}
+	for _, k := range sortBy {
+		key := SortKey{Key: strings.ToLower(k)}
+		switch key.Key[0] {
+		case '-':
+			key.Key, key.Desc = key.Key[1:], true
+		case '+':
+			key.Key = key.Key[1:]
+		}
+		if _, ok := validSortBy[key.Key]; !ok {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --sort value: %q\n\n%s", k, Usage())}
+		}
+		key.Desc = key.Desc != desc
+		cfg.Sort = append(cfg.Sort, key)
 	}
 	cfg.Format = strings.ToLower(cfg.Format)
 	if _, ok := validFormat[cfg.Format]; !ok {
@@ -107,20 +273,164 @@ func ParseArgs(args []string, stdin *os.File) (Config, error) {
 	if cfg.Jobs <= 0 {
 		cfg.Jobs = 0
 	}
+	if cfg.Top < 0 {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --top value: %d\n\n%s", cfg.Top, Usage())}
+	}
+	if cfg.MaxLineLength < 0 {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --max-line-length value: %d\n\n%s", cfg.MaxLineLength, Usage())}
+	}
+	if cfg.MaxDepth < 0 {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --max-depth value: %d\n\n%s", cfg.MaxDepth, Usage())}
+	}
+	if cfg.MaxDepth > 0 {
+		cfg.Recursive = true
+	}
+	for _, g := range append(append([]string{}, include...), exclude...) {
+		if err := glob.Valid(g); err != nil {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --include/--exclude pattern: %q\n\n%s", g, Usage())}
+		}
+	}
+	cfg.Include, cfg.Exclude = include, exclude
+	if minSize != "" {
+		n, err := util.ParseBytes(minSize)
+		if err != nil {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --min-size value: %q\n\n%s", minSize, Usage())}
+		}
+		cfg.MinSize = n
+	}
+	if maxSize != "" {
+		n, err := util.ParseBytes(maxSize)
+		if err != nil {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --max-size value: %q\n\n%s", maxSize, Usage())}
+		}
+		cfg.MaxSize = n
+	}
+	now := time.Now()
+	if newerThan != "" {
+		t, err := util.ParseTime(newerThan, now)
+		if err != nil {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --newer-than value: %q\n\n%s", newerThan, Usage())}
+		}
+		cfg.NewerThan = t
+	}
+	if olderThan != "" {
+		t, err := util.ParseTime(olderThan, now)
+		if err != nil {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --older-than value: %q\n\n%s", olderThan, Usage())}
+		}
+		cfg.OlderThan = t
+	}
+	if where != "" {
+		prog, err := expr.Parse(where, whereFields, now)
+		if err != nil {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --where value: %v\n\n%s", err, Usage())}
+		}
+		cfg.Where = prog
+	}
+	cfg.PathStyle = strings.ToLower(cfg.PathStyle)
+	if cfg.PathStyle == "" {
+		cfg.PathStyle = "base"
+		if cfg.RelativeTo != "" {
+			cfg.PathStyle = "relative"
+		}
+	}
+	if _, ok := validPathStyle[cfg.PathStyle]; !ok {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --path-style value: %q\n\n%s", cfg.PathStyle, Usage())}
+	}
+	for _, k := range groupBy {
+		k = strings.ToLower(k)
+		if _, ok := validGroupBy[k]; !ok {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --group-by value: %q\n\n%s", k, Usage())}
+		}
+		cfg.GroupBy = append(cfg.GroupBy, k)
+	}
+	for _, c := range columns {
+		c = strings.ToLower(c)
+		if _, ok := validColumns[c]; !ok {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --columns value: %q\n\n%s", c, Usage())}
+		}
+		cfg.Columns = append(cfg.Columns, c)
+	}
+	if len(cfg.Columns) > 0 && len(cfg.GroupBy) > 0 {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --columns usage: group reports have fixed columns\n\n%s", Usage())}
+	}
+	cfg.Hash = strings.ToLower(cfg.Hash)
+	if _, ok := validHash[cfg.Hash]; cfg.Hash != "" && !ok {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --hash value: %q\n\n%s", cfg.Hash, Usage())}
+	}
+	cfg.CharMode = strings.ToLower(cfg.CharMode)
+	if _, ok := validCharMode[cfg.CharMode]; !ok {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --char-mode value: %q\n\n%s", cfg.CharMode, Usage())}
+	}
+	cfg.Kind = strings.ToLower(cfg.Kind)
+	if _, ok := validKind[cfg.Kind]; cfg.Kind != "" && !ok {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --kind value: %q\n\n%s", cfg.Kind, Usage())}
+	}
+	switch {
+	case tmpl != "" && tmplFile != "":
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --template usage: use either --template or --template-file\n\n%s", Usage())}
+	case tmplFile != "":
+		b, err := os.ReadFile(tmplFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("Error reading --template-file: %w", err)
+		}
+		cfg.Template = string(b)
+	case tmpl != "":
+		cfg.Template = unescape(tmpl)
+	}
+	cfg.TemplateHeader = unescape(cfg.TemplateHeader)
+	cfg.TemplateFooter = unescape(cfg.TemplateFooter)
+	if cfg.Template != "" {
+		if len(cfg.GroupBy) > 0 {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --template usage: cannot be combined with --group-by\n\n%s", Usage())}
+		}
+		cfg.Format = "template"
+	} else if cfg.TemplateHeader != "" || cfg.TemplateFooter != "" {
+		return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --template-header/--template-footer usage: requires --template or --template-file\n\n%s", Usage())}
+	}
+
+	// ndjson is always streamed, and streamed JSON is ndjson.
+	switch {
+	case cfg.Format == "ndjson":
+		if len(cfg.GroupBy) > 0 {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --format value: ndjson cannot be combined with --group-by\n\n%s", Usage())}
+		}
+		cfg.Stream = true
+	case cfg.Stream && cfg.Format == "json":
+		cfg.Format = "ndjson"
+	}
+	if cfg.Stream {
+		if cfg.Format != "csv" && cfg.Format != "ndjson" {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --stream usage: requires --format csv, json or ndjson\n\n%s", Usage())}
+		}
+		if len(cfg.GroupBy) > 0 {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --stream usage: cannot be combined with --group-by\n\n%s", Usage())}
+		}
+		if cfg.Top > 0 {
+			return Config{}, &UsageError{Msg: fmt.Sprintf("Invalid --stream usage: cannot be combined with --top\n\n%s", Usage())}
+		}
+	}
+
+	if cfg.Checking() {
+		if err := checkChecks(cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	if cfg.Duplicates {
+		if err := checkDuplicates(cfg, where); err != nil {
+			return Config{}, err
+		}
+		if cfg.Hash == "" {
+			cfg.Hash = "sha256"
+		}
+	}
 
 	// Resolve files from remaining args or from stdin when piped
 	cfg.Files = fs.Args()
 	if len(cfg.Files) == 0 {
 		if stdin != nil {
 			if hasPipedInput(stdin) {
-				paths, err := readPathsFrom(stdin)
-				if err != nil {
-					return Config{}, err
-				}
-				if len(paths) == 0 {
-					return Config{}, &UsageError{Msg: "No file paths provided via stdin"}
-				}
-				cfg.Files = paths
+				cfg.Stdin = NewPathReader(stdin)
 			} else {
 				// No args and no piped stdin
 				return Config{}, &UsageError{Msg: Usage()}
@@ -133,6 +443,65 @@ func ParseArgs(args []string, stdin *os.File) (Config, error) {
 	return cfg, nil
 }
 
+// Checking reports whether a --check option replaces the normal output.
+func (c Config) Checking() bool {
+	return c.CheckHygiene || c.MaxLineLength > 0
+}
+
+// checkChecks rejects options that shape per-file or group rows, which
+// check reports do not have.
+func checkChecks(cfg Config) error {
+	var conflict string
+	switch {
+	case len(cfg.GroupBy) > 0:
+		conflict = "--group-by"
+	case cfg.Stream:
+		conflict = "--stream"
+	case len(cfg.Columns) > 0:
+		conflict = "--columns"
+	case cfg.Top > 0:
+		conflict = "--top"
+	case cfg.Duplicates:
+		conflict = "--duplicates"
+	case cfg.Format != "table" && cfg.Format != "csv" && cfg.Format != "json":
+		conflict = "--format " + cfg.Format
+	}
+	if conflict != "" {
+		flag := "--check-hygiene"
+		if !cfg.CheckHygiene {
+			flag = "--max-line-length"
+		}
+		return &UsageError{Msg: fmt.Sprintf("Invalid %s usage: cannot be combined with %s\n\n%s", flag, conflict, Usage())}
+	}
+	return nil
+}
+
+// checkDuplicates rejects options that need per-file analysis, which
+// --duplicates skips.
+func checkDuplicates(cfg Config, where string) error {
+	var conflict string
+	switch {
+	case len(cfg.GroupBy) > 0:
+		conflict = "--group-by"
+	case cfg.Stream:
+		conflict = "--stream"
+	case where != "":
+		conflict = "--where"
+	case len(cfg.Columns) > 0:
+		conflict = "--columns"
+	case cfg.Top > 0:
+		conflict = "--top"
+	case cfg.Format == "template":
+		conflict = "--template"
+	case cfg.Format == "html":
+		conflict = "--format html"
+	}
+	if conflict != "" {
+		return &UsageError{Msg: fmt.Sprintf("Invalid --duplicates usage: cannot be combined with %s\n\n%s", conflict, Usage())}
+	}
+	return nil
+}
+
 func defaultJobs() int {
 	return runtime.NumCPU()
 }
@@ -146,26 +515,49 @@ func hasPipedInput(stdin *os.File) bool {
 	return (info.Mode() & os.ModeCharDevice) == 0
 }
 
-func readPathsFrom(r *os.File) ([]string, error) {
+// unescape expands \n, \t and \\ in templates given on the command line,
+// where shells make real tabs and newlines awkward to type.
+var unescape = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t").Replace
+
+// PathReader streams newline-delimited paths, skipping blank lines.
+type PathReader struct {
+	sc  *bufio.Scanner
+	n   int
+	err error
+}
+
+func NewPathReader(r io.Reader) *PathReader {
 	sc := bufio.NewScanner(r)
 	// Increase scanner buffer for very long paths (rare but safe).
 	const maxCapacity = 1024 * 1024 // 1 MiB
 	buf := make([]byte, 64*1024)
 	sc.Buffer(buf, maxCapacity)
+	return &PathReader{sc: sc}
+}
 
-	var out []string
-	for sc.Scan() {
-		line := strings.TrimSpace(sc.Text())
-		if line != "" {
-			out = append(out, line)
+// All yields paths as they are read. It may be ranged over only once.
+func (p *PathReader) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for p.sc.Scan() {
+			line := strings.TrimSpace(p.sc.Text())
+			if line == "" {
+				continue
+			}
+			p.n++
+			if !yield(line) {
+				return
+			}
 		}
+		p.err = p.sc.Err()
 	}
-	if err := sc.Err(); err != nil {
-		return nil, err
-	}
-	return out, nil
 }
 
+// Count returns how many paths have been read so far.
+func (p *PathReader) Count() int { return p.n }
+
+// Err returns the first read error, if any.
+func (p *PathReader) Err() error { return p.err }
+
 // IsUsageError helps callers identify usage-related parse failures.
 func IsUsageError(err error) bool {
 	var ue *UsageError
@@ -0,0 +1,503 @@
+// Package expr implements the small boolean expression language used by
+// --where:
+//
+//	lines > 500 && ext in [".go", ".ts"] && modified > "2026-01-01"
+//
+// Expressions compare fields with literals using == != < <= > >=, test list
+// membership with in / not in, match regular expressions with =~ and !~, and
+// combine conditions with && (and), || (or), ! (not) and parentheses.
+// Number literals may carry a size unit (10MiB, 4k); strings compared with
+// a time field are parsed as dates or ages (2026-01-01, 7d).
+//
+// Fields and their types are declared by the caller, and every literal is
+// converted and type-checked when the expression is parsed, so evaluation
+// cannot fail.
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ADJB1212/Aperio/internal/util"
+)
+
+// Type is the type of a field or value.
+type Type int
+
+const (
+	Bool Type = iota
+	Number
+	String
+	Time
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	default:
+		return "time"
+	}
+}
+
+// Fields resolves a field to its current value: a bool, an integer or
+// float64 for Number fields, a string, or a time.Time.
+type Fields func(name string) any
+
+// Program is a parsed, type-checked expression.
+type Program struct {
+	root node
+}
+
+// Eval reports whether the expression holds for the given fields.
+func (p *Program) Eval(fields Fields) bool {
+	return p.root.eval(fields).b
+}
+
+// Parse compiles src. fields declares the names that may be referenced and
+// their types; now anchors relative ages such as "7d".
+func Parse(src string, fields map[string]Type, now time.Time) (*Program, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, fields: fields, now: now}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+	}
+	if root.typ() != Bool {
+		return nil, fmt.Errorf("expression is a %s, not a condition", root.typ())
+	}
+	return &Program{root: root}, nil
+}
+
+// value is the result of evaluating a node.
+type value struct {
+	t   Type
+	b   bool
+	num float64
+	str string
+	tm  time.Time
+}
+
+func compare(a, b value) int {
+	switch a.t {
+	case Number:
+		switch {
+		case a.num < b.num:
+			return -1
+		case a.num > b.num:
+			return 1
+		}
+		return 0
+	case String:
+		return strings.Compare(a.str, b.str)
+	case Time:
+		return a.tm.Compare(b.tm)
+	default:
+		if a.b == b.b {
+			return 0
+		}
+		if !a.b {
+			return -1
+		}
+		return 1
+	}
+}
+
+type node interface {
+	typ() Type
+	eval(f Fields) value
+}
+
+type constNode struct{ v value }
+
+func (n constNode) typ() Type           { return n.v.t }
+func (n constNode) eval(f Fields) value { return n.v }
+
+type fieldNode struct {
+	name string
+	t    Type
+}
+
+func (n fieldNode) typ() Type { return n.t }
+
+func (n fieldNode) eval(f Fields) value {
+	v := value{t: n.t}
+	switch x := f(n.name).(type) {
+	case bool:
+		v.b = x
+	case int:
+		v.num = float64(x)
+	case int64:
+		v.num = float64(x)
+	case float64:
+		v.num = x
+	case string:
+		v.str = x
+	case time.Time:
+		v.tm = x
+	}
+	return v
+}
+
+type notNode struct{ x node }
+
+func (n notNode) typ() Type           { return Bool }
+func (n notNode) eval(f Fields) value { return value{t: Bool, b: !n.x.eval(f).b} }
+
+type logicNode struct {
+	and  bool
+	l, r node
+}
+
+func (n logicNode) typ() Type { return Bool }
+
+func (n logicNode) eval(f Fields) value {
+	l := n.l.eval(f).b
+	if l != n.and {
+		// false && ..., true || ...
+		return value{t: Bool, b: l}
+	}
+	return value{t: Bool, b: n.r.eval(f).b}
+}
+
+type cmpNode struct {
+	op   string
+	l, r node
+}
+
+func (n cmpNode) typ() Type { return Bool }
+
+func (n cmpNode) eval(f Fields) value {
+	c := compare(n.l.eval(f), n.r.eval(f))
+	var b bool
+	switch n.op {
+	case "==":
+		b = c == 0
+	case "!=":
+		b = c != 0
+	case "<":
+		b = c < 0
+	case "<=":
+		b = c <= 0
+	case ">":
+		b = c > 0
+	case ">=":
+		b = c >= 0
+	}
+	return value{t: Bool, b: b}
+}
+
+type inNode struct {
+	x    node
+	list []value
+	not  bool
+}
+
+func (n inNode) typ() Type { return Bool }
+
+func (n inNode) eval(f Fields) value {
+	v := n.x.eval(f)
+	for _, item := range n.list {
+		if compare(v, item) == 0 {
+			return value{t: Bool, b: !n.not}
+		}
+	}
+	return value{t: Bool, b: n.not}
+}
+
+type matchNode struct {
+	x   node
+	re  *regexp.Regexp
+	not bool
+}
+
+func (n matchNode) typ() Type { return Bool }
+
+func (n matchNode) eval(f Fields) value {
+	return value{t: Bool, b: n.re.MatchString(n.x.eval(f).str) != n.not}
+}
+
+type parser struct {
+	toks   []token
+	i      int
+	fields map[string]Type
+	now    time.Time
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords (case-insensitive).
+func (p *parser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tOp && t.kind != tIdent {
+		return "", false
+	}
+	for _, s := range texts {
+		if strings.EqualFold(t.text, s) {
+			p.i++
+			return s, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return fmt.Errorf("expected %q but found %s at offset %d", op, t, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return l, nil
+		}
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if l, err = logic(false, l, r); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return l, nil
+		}
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if l, err = logic(true, l, r); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func logic(and bool, l, r node) (node, error) {
+	if l.typ() != Bool || r.typ() != Bool {
+		op := "||"
+		if and {
+			op = "&&"
+		}
+		return nil, fmt.Errorf("%s needs conditions on both sides", op)
+	}
+	return logicNode{and: and, l: l, r: r}, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != Bool {
+			return nil, fmt.Errorf("! needs a condition")
+		}
+		return notNode{x}, nil
+	}
+	return p.parseCmp()
+}
+
+func (p *parser) parseCmp() (node, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := p.accept("==", "!=", "<=", ">=", "<", ">"); ok {
+		r, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if l, r, err = p.unify(l, r); err != nil {
+			return nil, err
+		}
+		if l.typ() == Bool && op != "==" && op != "!=" {
+			return nil, fmt.Errorf("%s cannot compare conditions", op)
+		}
+		return cmpNode{op: op, l: l, r: r}, nil
+	}
+
+	if op, ok := p.accept("=~", "!~"); ok {
+		t := p.next()
+		if t.kind != tString {
+			return nil, fmt.Errorf("%s needs a quoted regular expression, found %s", op, t)
+		}
+		if l.typ() != String {
+			return nil, fmt.Errorf("%s needs a string on the left, not a %s", op, l.typ())
+		}
+		re, err := regexp.Compile(unquote(t.text))
+		if err != nil {
+			return nil, fmt.Errorf("bad regular expression %s: %v", t.text, err)
+		}
+		return matchNode{x: l, re: re, not: op == "!~"}, nil
+	}
+
+	not := false
+	if _, ok := p.accept("not"); ok {
+		not = true
+		if _, ok := p.accept("in"); !ok {
+			return nil, fmt.Errorf("expected \"in\" after \"not\"")
+		}
+	} else if _, ok := p.accept("in"); !ok {
+		return l, nil
+	}
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var list []value
+	for {
+		if _, ok := p.accept("]"); ok {
+			break
+		}
+		if len(list) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		item, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		c, ok := item.(constNode)
+		if !ok {
+			return nil, fmt.Errorf("list items must be literals")
+		}
+		v, err := p.convert(c, l.typ())
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return inNode{x: l, list: list, not: not}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	case tString:
+		return constNode{value{t: String, str: unquote(t.text)}}, nil
+	case tNumber:
+		n, err := parseNumber(t.text)
+		if err != nil {
+			// Ages like 7d are converted once the other side's type is known.
+			return constNode{value{t: String, str: t.text}}, nil
+		}
+		return constNode{value{t: Number, num: n}}, nil
+	case tIdent:
+		name := strings.ToLower(t.text)
+		switch name {
+		case "true", "false":
+			return constNode{value{t: Bool, b: name == "true"}}, nil
+		}
+		if typ, ok := p.fields[name]; ok {
+			return fieldNode{name: name, t: typ}, nil
+		}
+		return nil, fmt.Errorf("unknown field %q at offset %d", t.text, t.pos)
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
+
+// unify converts a literal on either side of a comparison to the type of
+// the other side, so `size > "1MiB"` and `modified > "7d"` work.
+func (p *parser) unify(l, r node) (node, node, error) {
+	if l.typ() == r.typ() {
+		return l, r, nil
+	}
+	if c, ok := r.(constNode); ok {
+		v, err := p.convert(c, l.typ())
+		return l, constNode{v}, err
+	}
+	if c, ok := l.(constNode); ok {
+		v, err := p.convert(c, r.typ())
+		return constNode{v}, r, err
+	}
+	return nil, nil, fmt.Errorf("cannot compare %s with %s", l.typ(), r.typ())
+}
+
+func (p *parser) convert(c constNode, to Type) (value, error) {
+	v := c.v
+	if v.t == to {
+		return v, nil
+	}
+	if v.t == String {
+		switch to {
+		case Number:
+			n, err := parseNumber(v.str)
+			return value{t: Number, num: n}, err
+		case Time:
+			tm, err := util.ParseTime(v.str, p.now)
+			if err != nil {
+				return value{}, fmt.Errorf("invalid date or age %q", v.str)
+			}
+			return value{t: Time, tm: tm}, nil
+		}
+	}
+	return value{}, fmt.Errorf("cannot compare %s with %s", to, v.t)
+}
+
+// parseNumber accepts plain numbers and sizes such as 10MiB or 1.5k.
+func parseNumber(s string) (float64, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, nil
+	}
+	n, err := util.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return float64(n), nil
+}
+
+// unquote strips the quotes from a string literal. Backslashes only escape
+// the quote character, so regular expressions need no doubling: "\.go$".
+func unquote(s string) string {
+	q := s[:1]
+	return strings.ReplaceAll(s[1:len(s)-1], `\`+q, q)
+}
@@ -0,0 +1,131 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+	testFields = map[string]Type{
+		"name":     String,
+		"ext":      String,
+		"size":     Number,
+		"lines":    Number,
+		"modified": Time,
+		"hidden":   Bool,
+	}
+
+	testValues = map[string]any{
+		"name":     "main.go",
+		"ext":      ".go",
+		"size":     int64(2048),
+		"lines":    500,
+		"modified": testNow.Add(-3 * 24 * time.Hour),
+		"hidden":   false,
+	}
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		// && binds tighter than ||, and ! tighter than both.
+		{`ext == ".go" || lines > 1000 && size > 1M`, true},
+		{`(ext == ".go" || lines > 1000) && size > 1M`, false},
+		{`lines > 1000 || ext == ".go" && size < 1k`, false},
+		{`!hidden && lines == 500`, true},
+		{`!(lines < 10)`, true},
+		{`not hidden and not lines > 100`, false},
+		{`LINES > 1 AND Ext == ".go" OR false`, true},
+		{`hidden == false`, true},
+
+		{`ext in [".go", ".rs"]`, true},
+		{`ext in []`, false},
+		{`ext not in [".go", ".rs"]`, false},
+		{`ext not in [".md", ".txt"]`, true},
+		{`not ext in [".go"]`, false},
+		{`lines in [100, 500]`, true},
+		{`size in ["2KiB"]`, true},
+
+		{`name =~ "^main\.go$"`, true},
+		{`name =~ 'MAIN'`, false},
+		{`name !~ "_test\.go$"`, true},
+		{`name !~ "\.go$"`, false},
+
+		// Bare and IEC units are binary, KB and friends decimal.
+		{`size == 2k`, true},
+		{`size == 2KiB`, true},
+		{`size > 2kb`, true},
+		{`size < 1.5k`, false},
+		{`size <= 0.002MB`, false},
+		{`size > "1MiB"`, false},
+		{`"1MiB" > size`, true},
+
+		// modified is three days before testNow.
+		{`modified > "2026-10-13"`, true},
+		{`modified > "2026-10-14"`, false},
+		{`modified < "2026-10-13 13:00"`, true},
+		{`modified >= "2026-10-13T12:00:00"`, true},
+		{`modified > 7d`, true},
+		{`modified > "2d"`, false},
+		{`modified < 48h`, true},
+		{`modified > 1w`, true},
+	}
+	for _, tt := range tests {
+		prog, err := Parse(tt.src, testFields, testNow)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", tt.src, err)
+			continue
+		}
+		if got := prog.Eval(func(name string) any { return testValues[name] }); got != tt.want {
+			t.Errorf("Eval(%s) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+// TestParseErrors covers the expressions that --where rejects as usage
+// errors.
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`lines > "abc"`, `invalid number "abc"`},
+		{`size < 10XB`, `invalid number "10XB"`},
+		{`modified > "yesterday"`, `invalid date or age "yesterday"`},
+		{`modified > 5`, "cannot compare time with number"},
+		{`ext == lines`, "cannot compare string with number"},
+		{`hidden == "yes"`, "cannot compare bool with string"},
+		{`lines in [1, "x"]`, `invalid number "x"`},
+		{`lines`, "expression is a number, not a condition"},
+		{`lines && ext == ".go"`, "&& needs conditions on both sides"},
+		{`hidden || name`, "|| needs conditions on both sides"},
+		{`!lines`, "! needs a condition"},
+		{`hidden < true`, "< cannot compare conditions"},
+		{`lines =~ "5"`, "=~ needs a string on the left, not a number"},
+		{`name !~ ext`, `!~ needs a quoted regular expression, found "ext"`},
+		{`name =~ "("`, `bad regular expression "("`},
+		{`size in [1k, size]`, "list items must be literals"},
+		{`ext not [".go"]`, `expected "in" after "not"`},
+		{`ext in ".go"`, `expected "[" but found "\".go\""`},
+		{`nope > 1`, `unknown field "nope" at offset 0`},
+		{`lines > 1 )`, `unexpected ")" at offset 10`},
+		{`(lines > 1`, `expected ")" but found end of expression`},
+		{`name == "main.go`, "unterminated string at offset 8"},
+		{`lines > 1 $`, `unexpected '$' at offset 10`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src, testFields, testNow)
+		if err == nil {
+			t.Errorf("Parse(%s) succeeded, want error %q", tt.src, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s) error = %q, want %q", tt.src, err, tt.want)
+		}
+	}
+}
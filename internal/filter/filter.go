@@ -0,0 +1,109 @@
+package filter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/glob"
+)
+
+// Filter selects files by path globs and file attributes before they are
+// analyzed. The zero value keeps everything.
+type Filter struct {
+	// Include, when non-empty, keeps only files matching at least one glob.
+	Include []string
+	// Exclude drops files, and prunes directories, matching any glob.
+	Exclude []string
+	// MinSize and MaxSize bound the file size in bytes; 0 means no bound.
+	MinSize int64
+	MaxSize int64
+	// NewerThan and OlderThan bound the modification time; zero means no bound.
+	NewerThan time.Time
+	OlderThan time.Time
+	// Kind keeps only "text" or "binary" files. It is the only check that
+	// opens the file, to sniff its first few KiB.
+	Kind string
+}
+
+// Active reports whether any criterion is set.
+func (f *Filter) Active() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0 || f.MinSize > 0 || f.MaxSize > 0 ||
+		!f.NewerThan.IsZero() || !f.OlderThan.IsZero() || f.Kind != ""
+}
+
+// KeepDir reports whether the walk should descend into the directory rel.
+func (f *Filter) KeepDir(rel string) bool {
+	return !matchAny(f.Exclude, rel)
+}
+
+// KeepFile reports whether the file at path should be analyzed. rel is the
+// path relative to the walk root it was found under; d may be nil.
+func (f *Filter) KeepFile(path, rel string, d fs.DirEntry) bool {
+	if matchAny(f.Exclude, rel) {
+		return false
+	}
+	if len(f.Include) > 0 && !matchAny(f.Include, rel) {
+		return false
+	}
+
+	if f.MinSize > 0 || f.MaxSize > 0 || !f.NewerThan.IsZero() || !f.OlderThan.IsZero() {
+		info, err := stat(path, d)
+		if err != nil {
+			// Let the analyzer report the error row.
+			return true
+		}
+		if f.MinSize > 0 && info.Size() < f.MinSize {
+			return false
+		}
+		if f.MaxSize > 0 && info.Size() > f.MaxSize {
+			return false
+		}
+		if !f.NewerThan.IsZero() && !info.ModTime().After(f.NewerThan) {
+			return false
+		}
+		if !f.OlderThan.IsZero() && !info.ModTime().Before(f.OlderThan) {
+			return false
+		}
+	}
+
+	if f.Kind != "" {
+		kind, err := analyze.SniffKind(path)
+		if err != nil {
+			return true
+		}
+		return kind == f.Kind
+	}
+	return true
+}
+
+// stat prefers the walk's cached entry info, resolving symlinks to their target.
+func stat(path string, d fs.DirEntry) (fs.FileInfo, error) {
+	if d != nil && d.Type()&fs.ModeSymlink == 0 {
+		return d.Info()
+	}
+	return os.Stat(path)
+}
+
+// matchAny matches globs gitignore-style: a pattern without a slash is
+// compared with the base name, otherwise with the whole relative path.
+func matchAny(patterns []string, rel string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	base := rel[strings.LastIndexByte(rel, '/')+1:]
+	for _, p := range patterns {
+		target := base
+		if strings.Contains(p, "/") {
+			target = rel
+		}
+		if glob.Match(strings.TrimPrefix(p, "./"), target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+// Package grapheme finds extended grapheme cluster boundaries, the
+// user-perceived characters of UAX #29: a base character with its combining
+// marks, a Hangul syllable, an emoji ZWJ sequence or a flag count as one.
+// The Indic conjunct rule (GB9c) is not applied.
+package grapheme
+
+import "unicode"
+
+// prop is a Grapheme_Cluster_Break property value.
+type prop uint8
+
+const (
+	other prop = iota
+	cr
+	lf
+	control
+	extend
+	zwj
+	regionalIndicator
+	prepend
+	spacingMark
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+	extPict
+)
+
+// Segmenter tracks the state needed to place boundaries in a stream of
+// runes. The zero value is ready to use.
+type Segmenter struct {
+	started bool
+	prev    prop
+	// pict is set while the runes since the last Extended_Pictographic
+	// are only Extend, optionally ending in a ZWJ.
+	pict bool
+	// ri counts the regional indicators in a row ending at prev.
+	ri int
+}
+
+// Boundary reports whether a new cluster starts at r, which follows the
+// runes seen so far. The first rune always starts one.
+func (s *Segmenter) Boundary(r rune) bool {
+	p := property(r)
+	brk := !s.started || s.breaks(p)
+
+	switch {
+	case p == extPict:
+		s.pict = true
+	case (p == extend || p == zwj) && s.prev != zwj:
+	default:
+		s.pict = false
+	}
+	if p == regionalIndicator {
+		s.ri++
+	} else {
+		s.ri = 0
+	}
+	s.started, s.prev = true, p
+	return brk
+}
+
+// breaks applies the boundary rules between the previous rune and one with
+// property p.
+func (s *Segmenter) breaks(p prop) bool {
+	prev := s.prev
+	switch {
+	case prev == cr && p == lf: // GB3
+		return false
+	case prev == cr, prev == lf, prev == control: // GB4
+		return true
+	case p == cr, p == lf, p == control: // GB5
+		return true
+	case prev == hangulL && (p == hangulL || p == hangulV || p == hangulLV || p == hangulLVT): // GB6
+		return false
+	case (prev == hangulLV || prev == hangulV) && (p == hangulV || p == hangulT): // GB7
+		return false
+	case (prev == hangulLVT || prev == hangulT) && p == hangulT: // GB8
+		return false
+	case p == extend, p == zwj, p == spacingMark: // GB9, GB9a
+		return false
+	case prev == prepend: // GB9b
+		return false
+	case prev == zwj && p == extPict && s.pict: // GB11
+		return false
+	case prev == regionalIndicator && p == regionalIndicator && s.ri%2 == 1: // GB12, GB13
+		return false
+	}
+	return true
+}
+
+// Count returns the number of grapheme clusters in s.
+func Count(s string) int {
+	var seg Segmenter
+	n := 0
+	for _, r := range s {
+		if seg.Boundary(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func property(r rune) prop {
+	if r < 0x80 {
+		switch {
+		case r == '\r':
+			return cr
+		case r == '\n':
+			return lf
+		case r < 0x20 || r == 0x7f:
+			return control
+		}
+		return other
+	}
+	switch {
+	case r == 0x200d:
+		return zwj
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		return regionalIndicator
+	case r >= 0xac00 && r <= 0xd7a3:
+		// Precomposed syllables: LV when there is no final consonant.
+		if (r-0xac00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return hangulL
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return hangulV
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return hangulT
+	case unicode.Is(prependTable, r):
+		return prepend
+	case unicode.In(r, unicode.Mn, unicode.Me, extendTable):
+		return extend
+	case unicode.In(r, unicode.Mc, spacingMarkTable):
+		return spacingMark
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp, unicode.Cs):
+		return control
+	case unicode.Is(extPictTable, r):
+		return extPict
+	}
+	return other
+}
+
+// extendTable holds Extend characters outside Mn and Me: ZWNJ, the
+// halfwidth sound marks, the emoji skin tone modifiers and the tags used in
+// subdivision flags.
+var extendTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x200c, Hi: 0x200c, Stride: 1},
+		{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f3fb, Hi: 0x1f3ff, Stride: 1},
+		{Lo: 0xe0020, Hi: 0xe007f, Stride: 1},
+	},
+}
+
+// spacingMarkTable holds SpacingMark characters outside Mc.
+var spacingMarkTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0e33, Hi: 0x0eb3, Stride: 0x80}, // Thai and Lao SARA AM
+	},
+}
+
+var prependTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0600, Hi: 0x0605, Stride: 1},
+		{Lo: 0x06dd, Hi: 0x06dd, Stride: 1},
+		{Lo: 0x070f, Hi: 0x070f, Stride: 1},
+		{Lo: 0x0890, Hi: 0x0891, Stride: 1},
+		{Lo: 0x08e2, Hi: 0x08e2, Stride: 1},
+		{Lo: 0x0d4e, Hi: 0x0d4e, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x110bd, Hi: 0x110cd, Stride: 0x10},
+		{Lo: 0x111c2, Hi: 0x111c3, Stride: 1},
+		{Lo: 0x1193f, Hi: 0x11941, Stride: 2},
+		{Lo: 0x11a3a, Hi: 0x11a3a, Stride: 1},
+		{Lo: 0x11a84, Hi: 0x11a89, Stride: 1},
+		{Lo: 0x11d46, Hi: 0x11d46, Stride: 1},
+		{Lo: 0x11f02, Hi: 0x11f02, Stride: 1},
+	},
+}
+
+// extPictTable holds the Extended_Pictographic characters of emoji-data.txt,
+// with adjacent ranges merged.
+var extPictTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a9, Hi: 0x00ae, Stride: 5},
+		{Lo: 0x203c, Hi: 0x2049, Stride: 13},
+		{Lo: 0x2122, Hi: 0x2139, Stride: 23},
+		{Lo: 0x2194, Hi: 0x2199, Stride: 1},
+		{Lo: 0x21a9, Hi: 0x21aa, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2328, Hi: 0x2388, Stride: 0x60},
+		{Lo: 0x23cf, Hi: 0x23cf, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x23f8, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25ab, Stride: 1},
+		{Lo: 0x25b6, Hi: 0x25c0, Stride: 10},
+		{Lo: 0x25fb, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2600, Hi: 0x2605, Stride: 1},
+		{Lo: 0x2607, Hi: 0x2612, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2685, Stride: 1},
+		{Lo: 0x2690, Hi: 0x2705, Stride: 1},
+		{Lo: 0x2708, Hi: 0x2712, Stride: 1},
+		{Lo: 0x2714, Hi: 0x2716, Stride: 2},
+		{Lo: 0x271d, Hi: 0x2721, Stride: 4},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x2733, Hi: 0x2734, Stride: 1},
+		{Lo: 0x2744, Hi: 0x2747, Stride: 3},
+		{Lo: 0x274c, Hi: 0x274e, Stride: 2},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2763, Hi: 0x2767, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27a1, Hi: 0x27a1, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27bf, Stride: 15},
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b07, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b55, Stride: 5},
+		{Lo: 0x3030, Hi: 0x303d, Stride: 13},
+		{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1f0ff, Stride: 1},
+		{Lo: 0x1f10d, Hi: 0x1f10f, Stride: 1},
+		{Lo: 0x1f12f, Hi: 0x1f12f, Stride: 1},
+		{Lo: 0x1f16c, Hi: 0x1f171, Stride: 1},
+		{Lo: 0x1f17e, Hi: 0x1f17f, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f1ad, Hi: 0x1f1e5, Stride: 1},
+		{Lo: 0x1f201, Hi: 0x1f20f, Stride: 1},
+		{Lo: 0x1f21a, Hi: 0x1f22f, Stride: 21},
+		{Lo: 0x1f232, Hi: 0x1f23a, Stride: 1},
+		{Lo: 0x1f23c, Hi: 0x1f23f, Stride: 1},
+		{Lo: 0x1f249, Hi: 0x1f3fa, Stride: 1},
+		{Lo: 0x1f400, Hi: 0x1f53d, Stride: 1},
+		{Lo: 0x1f546, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f774, Hi: 0x1f77f, Stride: 1},
+		{Lo: 0x1f7d5, Hi: 0x1f7ff, Stride: 1},
+		{Lo: 0x1f80c, Hi: 0x1f80f, Stride: 1},
+		{Lo: 0x1f848, Hi: 0x1f84f, Stride: 1},
+		{Lo: 0x1f85a, Hi: 0x1f85f, Stride: 1},
+		{Lo: 0x1f888, Hi: 0x1f88f, Stride: 1},
+		{Lo: 0x1f8ae, Hi: 0x1f8ff, Stride: 1},
+		{Lo: 0x1f90c, Hi: 0x1f93a, Stride: 1},
+		{Lo: 0x1f93c, Hi: 0x1f945, Stride: 1},
+		{Lo: 0x1f947, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x1fc00, Hi: 0x1fffd, Stride: 1},
+	},
+}
@@ -0,0 +1,51 @@
+package grapheme
+
+import "testing"
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "abc", 3},
+		{"control", "a\tb", 3},
+		{"crlf", "a\r\nb", 3},
+		{"lfcr", "\n\r", 2},
+		{"combining", "e\u0301e\u0301", 2},
+		{"hangul syllable", "\ud55c\uad6d", 2},
+		{"hangul jamo", "\u1100\u1161\u11a8", 1},
+		{"hangul lv t", "\uac00\u11a8", 1},
+		{"thai sara am", "\u0e01\u0e33", 1},
+		{"prepend", "\u0600a", 1},
+		{"skin tone", "\U0001f44d\U0001f3fd", 1},
+		{"zwj family", "\U0001f468\u200d\U0001f469\u200d\U0001f467", 1},
+		{"zwj after letter", "a\u200d\U0001f600", 2},
+		{"emoji presentation", "\u2764\ufe0f", 1},
+		{"flags", "\U0001f1e9\U0001f1ea\U0001f1eb\U0001f1f7", 2},
+		{"odd regional indicators", "\U0001f1e9\U0001f1ea\U0001f1eb", 2},
+		{"subdivision flag", "\U0001f3f4\U000e0067\U000e0062\U000e0065\U000e006e\U000e0067\U000e007f", 1},
+		// GB9c is not applied, so Indic conjuncts split after the virama.
+		{"conjunct", "\u0915\u094d\u0937\u093f", 2},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.s); got != tt.want {
+			t.Errorf("%s: Count(%+q) = %d, want %d", tt.name, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestBoundary(t *testing.T) {
+	var s Segmenter
+	var got []bool
+	for _, r := range "a\u0301\U0001f1e9\U0001f1ea\r\n" {
+		got = append(got, s.Boundary(r))
+	}
+	want := []bool{true, false, true, false, true, false}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Boundary = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package icons
+
+import (
+	"testing"
+
+	"github.com/ADJB1212/Aperio/internal/lang"
+)
+
+func TestIcon(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"Makefile", ""},
+		{"src/CMakeLists.txt", ""},
+		{"config.yaml", ""},
+		{"run.sh", ""},
+		{"/home/u/.bashrc", ""},
+		{"notes.xyz", ""},
+		{"README", ""},
+	}
+	for _, tt := range tests {
+		if got := Icon(tt.path); got != tt.want {
+			t.Errorf("Icon(%q) = %+q, want %+q", tt.path, got, tt.want)
+		}
+	}
+	for _, ext := range lang.Extensions() {
+		if Icon("f"+ext) == "" {
+			t.Errorf("no icon for %s", ext)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ADJB1212/Aperio/internal/glob"
+)
+
+// FileNames lists the ignore files read from each directory, lowest precedence
+// first: rules in later files override earlier ones.
+var FileNames = []string{".gitignore", ".ignore", ".aperioignore"}
+
+type rule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// Matcher holds the ignore rules declared in one directory and falls back to
+// the matcher of its parent directory when none of them apply.
+type Matcher struct {
+	dir string
+	// prefix is set for rules declared above the walk root: dir is then the
+	// root, and prefix the root's slash-separated path below the directory
+	// that declared them.
+	prefix string
+	rules  []rule
+	parent *Matcher
+}
+
+// Load reads the ignore files in realDir and returns a matcher whose patterns
+// are relative to dir, the path the walk reports for that directory. If the
+// directory declares no rules, parent is returned unchanged.
+func Load(realDir, dir string, parent *Matcher) *Matcher {
+	var rules []rule
+	for _, name := range FileNames {
+		rules = append(rules, readRules(filepath.Join(realDir, name))...)
+	}
+	if len(rules) == 0 {
+		return parent
+	}
+	return &Matcher{dir: dir, rules: rules, parent: parent}
+}
+
+// LoadAncestors returns the rules declared in the ancestors of the walk
+// root realRoot, which the walk reports as root, from the top of its git
+// repository down. Outside a repository, or at its top, it returns nil.
+func LoadAncestors(realRoot, root string) *Matcher {
+	var dirs []string
+	for dir := realRoot; !exists(filepath.Join(dir, ".git")); {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+	var m *Matcher
+	for i := len(dirs) - 1; i >= 0; i-- {
+		prefix, err := filepath.Rel(dirs[i], realRoot)
+		if err != nil {
+			continue
+		}
+		if next := Load(dirs[i], root, m); next != m {
+			next.prefix = filepath.ToSlash(prefix)
+			m = next
+		}
+	}
+	return m
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Match reports whether path (a descendant of the matcher's directory) is
+// ignored. The last matching rule in the deepest directory wins.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	for ; m != nil; m = m.parent {
+		rel, err := filepath.Rel(m.dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if m.prefix != "" {
+			rel = m.prefix + "/" + rel
+		}
+		base := rel[strings.LastIndexByte(rel, '/')+1:]
+		for i := len(m.rules) - 1; i >= 0; i-- {
+			r := m.rules[i]
+			if r.dirOnly && !isDir {
+				continue
+			}
+			target := base
+			if r.anchored {
+				target = rel
+			}
+			if glob.Match(r.pattern, target) {
+				return !r.negate
+			}
+		}
+	}
+	return false
+}
+
+func readRules(path string) []rule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []rule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if r, ok := parseLine(sc.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseLine converts one line of an ignore file using gitignore syntax.
+func parseLine(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are dropped unless escaped with a backslash.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		r.anchored = true
+		line = strings.TrimLeft(line, "/")
+	} else if strings.Contains(line, "/") {
+		r.anchored = true
+	}
+	if line == "" || glob.Valid(line) != nil {
+		return rule{}, false
+	}
+	r.pattern = line
+	return r, true
+}
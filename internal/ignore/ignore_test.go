@@ -0,0 +1,115 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), `# build output
+*.log
+!keep.log
+/build
+docs/*.tmp
+logs/
+**/cache/**
+a/**/z.txt
+\!bang
+trailing.txt  
+`)
+	writeFile(t, filepath.Join(root, "sub", ".gitignore"), "!debug.log\n")
+	m := Load(root, root, nil)
+	sub := Load(filepath.Join(root, "sub"), filepath.Join(root, "sub"), m)
+
+	tests := []struct {
+		m     *Matcher
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{m, "app.log", false, true},
+		{m, "deep/dir/app.log", false, true},
+		{m, "keep.log", false, false},
+		{m, "deep/keep.log", false, false},
+		{m, "app.go", false, false},
+
+		// A leading slash, or a slash inside the pattern, anchors it.
+		{m, "build", true, true},
+		{m, "src/build", true, false},
+		{m, "docs/a.tmp", false, true},
+		{m, "src/docs/a.tmp", false, false},
+
+		// A trailing slash only matches directories.
+		{m, "logs", true, true},
+		{m, "logs", false, false},
+
+		{m, "cache/x", false, true},
+		{m, "a/b/cache/x/y", false, true},
+		{m, "cache", true, false},
+		{m, "a/z.txt", false, true},
+		{m, "a/b/c/z.txt", false, true},
+		{m, "b/z.txt", false, false},
+
+		{m, "!bang", false, true},
+		{m, "trailing.txt", false, true},
+
+		// A deeper directory overrides its parent, only below itself.
+		{sub, "sub/debug.log", false, false},
+		{sub, "sub/other.log", false, true},
+		{sub, "debug.log", false, true},
+
+		// Names that merely start with ".." are inside the directory.
+		{m, "..foo.log", false, true},
+		{sub, "..foo/debug.log", false, true},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Match(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+			t.Errorf("Match(%s, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAncestors(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repo, ".gitignore"), "*.log\n/top.txt\n/src/gen/\n")
+	writeFile(t, filepath.Join(repo, "src", ".gitignore"), "!keep.log\n")
+	root := filepath.Join(repo, "src")
+
+	m := LoadAncestors(root, "src")
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"src/app.log", false, true},
+		{"src/keep.log", false, true}, // the root's own files are left to the walk
+		{"src/gen", true, true},
+		{"src/pkg/gen", true, false},
+		{"src/top.txt", false, false},
+		{"src/app.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(filepath.FromSlash(tt.path), tt.isDir); got != tt.want {
+			t.Errorf("Match(%s, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	if m := LoadAncestors(repo, repo); m != nil {
+		t.Errorf("LoadAncestors at the repository top = %v, want nil", m)
+	}
+}
@@ -0,0 +1,169 @@
+package lang
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// perl is only reachable through shebangs and modelines; it has no icon key.
+var perl = &Language{Name: "Perl", LineComments: hash, BlockComments: []Block{{"=pod", "=cut"}}, Quotes: "\"'"}
+
+// interpreters maps shebang interpreter names, with version suffixes removed,
+// to languages.
+var interpreters = map[string]*Language{
+	"sh":         shell,
+	"bash":       shell,
+	"zsh":        shell,
+	"ksh":        shell,
+	"dash":       shell,
+	"ash":        shell,
+	"fish":       fish,
+	"python":     python,
+	"pypy":       python,
+	"node":       javaScript,
+	"nodejs":     javaScript,
+	"deno":       typeScript,
+	"bun":        javaScript,
+	"ts-node":    typeScript,
+	"tsx":        typeScript,
+	"ruby":       ruby,
+	"php":        php,
+	"lua":        lua,
+	"luajit":     lua,
+	"runhaskell": haskell,
+	"runghc":     haskell,
+	"swift":      swift,
+	"kotlin":     kotlin,
+	"scala":      scala,
+	"make":       makefile,
+	"perl":       perl,
+}
+
+// modeNames maps vim filetypes and emacs modes to languages. Language names
+// (lower-cased) are accepted as well.
+var modeNames = map[string]*Language{
+	"sh":           shell,
+	"bash":         shell,
+	"zsh":          shell,
+	"shell":        shell,
+	"shell-script": shell,
+	"js":           javaScript,
+	"js2":          javaScript,
+	"ts":           typeScript,
+	"py":           python,
+	"rb":           ruby,
+	"cpp":          cpp,
+	"c++":          cpp,
+	"objc":         objC,
+	"objcpp":       objCpp,
+	"cs":           cSharp,
+	"csharp":       cSharp,
+	"make":         makefile,
+	"makefile":     makefile,
+	"dockerfile":   dockerfile,
+	"yml":          yaml,
+	"md":           markdown,
+	"dosini":       ini,
+	"vim":          vim,
+	"hs":           haskell,
+	"perl":         perl,
+	"cperl":        perl,
+}
+
+var allLanguages = []*Language{
+	goLang, rust, zig, javaScript, typeScript, python, cLang, cpp, objC, objCpp, cSharp,
+	java, kotlin, scala, swift, ruby, erb, php, lua, haskell, vim, html, css, scss, sass,
+	less, json, jsonc, json5, yaml, toml, ini, conf, markdown, shell, fish, makefile,
+	cmake, dockerfile, perl,
+}
+
+func init() {
+	for _, l := range allLanguages {
+		key := strings.ToLower(l.Name)
+		if _, ok := modeNames[key]; !ok {
+			modeNames[key] = l
+		}
+	}
+}
+
+var (
+	// vim: set ft=python:   vi: filetype=sh   ex: syntax=ruby
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+#-]+)`)
+	// -*- mode: python -*-   -*- python -*-   -*- mode: sh; coding: utf-8 -*-
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([\w+#-]+)|([\w+#-]+)\s*-\*-)`)
+)
+
+// modelineLines is how many leading lines are searched for modelines.
+const modelineLines = 5
+
+// Detect identifies the language of a file from, in order of precedence, a
+// vim or emacs modeline near the top of head, its file name, a shebang line,
+// and its extension. head is the start of the file's content. It returns nil
+// if the language cannot be determined.
+func Detect(path string, head []byte) *Language {
+	if l := fromModeline(head); l != nil {
+		return l
+	}
+	base := filepath.Base(path)
+	if l, ok := nameToLang[strings.ToLower(base)]; ok {
+		return l
+	}
+	if l := fromShebang(head); l != nil {
+		return l
+	}
+	return ForPath(path)
+}
+
+func fromShebang(head []byte) *Language {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return nil
+	}
+	line := head[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return nil
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		// Skip env's own options, e.g. `env -S node --flag`.
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = filepath.Base(f)
+				break
+			}
+		}
+	}
+	// python3.12 -> python, lua5.4 -> lua
+	interp = strings.TrimRight(interp, "0123456789.")
+	return interpreters[interp]
+}
+
+func fromModeline(head []byte) *Language {
+	for n := 0; n < modelineLines && len(head) > 0; n++ {
+		line := head
+		if i := bytes.IndexByte(head, '\n'); i >= 0 {
+			line, head = head[:i], head[i+1:]
+		} else {
+			head = nil
+		}
+		var mode string
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			mode = string(m[1])
+		} else if m := emacsModeline.FindSubmatch(line); m != nil {
+			mode = string(m[1]) + string(m[2])
+		}
+		if mode == "" {
+			continue
+		}
+		if l, ok := modeNames[strings.ToLower(mode)]; ok {
+			return l
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package lang
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Block is a pair of block comment delimiters.
+type Block struct {
+	Start string
+	End   string
+}
+
+// Language describes the comment syntax needed to classify source lines.
+type Language struct {
+	Name          string
+	LineComments  []string
+	BlockComments []Block
+	// Nested reports whether block comments nest, as in Rust or Haskell.
+	Nested bool
+	// Quotes lists the characters that open single-line string literals, so
+	// comment delimiters inside strings are not mistaken for comments.
+	Quotes string
+	// Icon is the Nerd Font glyph shown next to the extension.
+	Icon string
+}
+
+// configIcon and shellIcon are shared by several languages.
+const (
+	configIcon = ""
+	shellIcon  = ""
+)
+
+var (
+	cStyle    = []Block{{"/*", "*/"}}
+	slashes   = []string{"//"}
+	hash      = []string{"#"}
+	htmlBlock = []Block{{"<!--", "-->"}}
+)
+
+var (
+	goLang     = &Language{Name: "Go", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: ""}
+	rust       = &Language{Name: "Rust", LineComments: slashes, BlockComments: cStyle, Nested: true, Quotes: "\"", Icon: ""}
+	zig        = &Language{Name: "Zig", LineComments: slashes, Quotes: "\"", Icon: ""}
+	javaScript = &Language{Name: "JavaScript", LineComments: slashes, BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	typeScript = &Language{Name: "TypeScript", LineComments: slashes, BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	python     = &Language{Name: "Python", LineComments: hash, Quotes: "\"'", Icon: ""}
+	cLang      = &Language{Name: "C", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: ""}
+	cpp        = &Language{Name: "C++", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: ""}
+	objC       = &Language{Name: "Objective-C", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: ""}
+	objCpp     = &Language{Name: "Objective-C++", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: ""}
+	cSharp     = &Language{Name: "C#", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: "󰌛"}
+	java       = &Language{Name: "Java", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: ""}
+	kotlin     = &Language{Name: "Kotlin", LineComments: slashes, BlockComments: cStyle, Nested: true, Quotes: "\"", Icon: ""}
+	scala      = &Language{Name: "Scala", LineComments: slashes, BlockComments: cStyle, Nested: true, Quotes: "\"", Icon: ""}
+	swift      = &Language{Name: "Swift", LineComments: slashes, BlockComments: cStyle, Nested: true, Quotes: "\"", Icon: ""}
+	ruby       = &Language{Name: "Ruby", LineComments: hash, BlockComments: []Block{{"=begin", "=end"}}, Quotes: "\"'", Icon: ""}
+	erb        = &Language{Name: "ERB", BlockComments: []Block{{"<%#", "%>"}, {"<!--", "-->"}}, Icon: ""}
+	php        = &Language{Name: "PHP", LineComments: []string{"//", "#"}, BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	lua        = &Language{Name: "Lua", LineComments: []string{"--"}, BlockComments: []Block{{"--[[", "]]"}}, Quotes: "\"'", Icon: ""}
+	haskell    = &Language{Name: "Haskell", LineComments: []string{"--"}, BlockComments: []Block{{"{-", "-}"}}, Nested: true, Quotes: "\"", Icon: ""}
+	vim        = &Language{Name: "Vim Script", LineComments: []string{"\""}, Icon: ""}
+	html       = &Language{Name: "HTML", BlockComments: htmlBlock, Icon: ""}
+	css        = &Language{Name: "CSS", BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	scss       = &Language{Name: "SCSS", LineComments: slashes, BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	sass       = &Language{Name: "Sass", LineComments: slashes, BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	less       = &Language{Name: "Less", LineComments: slashes, BlockComments: cStyle, Quotes: "\"'", Icon: ""}
+	json       = &Language{Name: "JSON", Quotes: "\"", Icon: "󰘦"}
+	jsonc      = &Language{Name: "JSON with Comments", LineComments: slashes, BlockComments: cStyle, Quotes: "\"", Icon: "󰘦"}
+	json5      = &Language{Name: "JSON5", LineComments: slashes, BlockComments: cStyle, Quotes: "\"'", Icon: "󰘦"}
+	yaml       = &Language{Name: "YAML", LineComments: hash, Quotes: "\"'", Icon: configIcon}
+	toml       = &Language{Name: "TOML", LineComments: hash, Quotes: "\"'", Icon: configIcon}
+	ini        = &Language{Name: "INI", LineComments: []string{";", "#"}, Icon: configIcon}
+	conf       = &Language{Name: "Config", LineComments: hash, Icon: configIcon}
+	markdown   = &Language{Name: "Markdown", BlockComments: htmlBlock, Icon: ""}
+	shell      = &Language{Name: "Shell", LineComments: hash, Quotes: "\"'", Icon: shellIcon}
+	fish       = &Language{Name: "Fish", LineComments: hash, Quotes: "\"'", Icon: shellIcon}
+	makefile   = &Language{Name: "Makefile", LineComments: hash, Icon: configIcon}
+	cmake      = &Language{Name: "CMake", LineComments: hash, BlockComments: []Block{{"#[[", "]]"}}, Quotes: "\"", Icon: configIcon}
+	dockerfile = &Language{Name: "Dockerfile", LineComments: hash, Icon: ""}
+)
+
+// nameToLang and extToLang are keyed by lower-cased base names and
+// extensions. They are the one table of known files: internal/icons reads
+// its icons from the languages found here.
+var nameToLang = map[string]*Language{
+	"makefile":       makefile,
+	"cmakelists.txt": cmake,
+	"dockerfile":     dockerfile,
+	".bashrc":        shell,
+	".zshrc":         shell,
+	".profile":       shell,
+	".bash_profile":  shell,
+}
+
+var extToLang = map[string]*Language{
+	".go":  goLang,
+	".rs":  rust,
+	".zig": zig,
+
+	".js":  javaScript,
+	".jsx": javaScript,
+	".ts":  typeScript,
+	".tsx": typeScript,
+	".mjs": javaScript,
+	".cjs": javaScript,
+
+	".py":  python,
+	".pyw": python,
+
+	".c":   cLang,
+	".h":   cLang,
+	".hpp": cpp,
+	".hh":  cpp,
+	".hxx": cpp,
+	".cc":  cpp,
+	".cpp": cpp,
+	".cxx": cpp,
+	".m":   objC,
+	".mm":  objCpp,
+	".cs":  cSharp,
+	".csx": cSharp,
+
+	".java":  java,
+	".kt":    kotlin,
+	".kts":   kotlin,
+	".scala": scala,
+	".swift": swift,
+
+	".rb":  ruby,
+	".erb": erb,
+	".php": php,
+
+	".lua": lua,
+	".hs":  haskell,
+
+	".vim": vim,
+
+	".html": html,
+	".htm":  html,
+	".css":  css,
+	".scss": scss,
+	".sass": sass,
+	".less": less,
+
+	".json":     json,
+	".jsonc":    jsonc,
+	".jsonl":    json,
+	".json5":    json5,
+	".yaml":     yaml,
+	".yml":      yaml,
+	".toml":     toml,
+	".ini":      ini,
+	".conf":     conf,
+	".md":       markdown,
+	".markdown": markdown,
+
+	".sh":   shell,
+	".bash": shell,
+	".zsh":  shell,
+	".ksh":  shell,
+	".fish": fish,
+}
+
+// ForPath returns the language for a file by its base name or extension,
+// or nil if it is not known.
+func ForPath(path string) *Language {
+	base := filepath.Base(path)
+	if l, ok := nameToLang[strings.ToLower(base)]; ok {
+		return l
+	}
+	ext := strings.ToLower(filepath.Ext(base))
+	if ext == "" {
+		return nil
+	}
+	return extToLang[ext]
+}
+
+// Extensions returns the known extensions, in no particular order.
+func Extensions() []string {
+	out := make([]string, 0, len(extToLang))
+	for ext := range extToLang {
+		out = append(out, ext)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package run
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/cli"
+)
+
+// exitCheckFailed is returned when a --check option finds a problem.
+const exitCheckFailed = 3
+
+// finding is one problem reported by a --check option. Line is 0 for
+// problems with the file as a whole.
+type finding struct {
+	Path    string
+	Line    int `json:",omitempty"`
+	Check   string
+	Message string
+}
+
+// hygieneFindings reports mixed line endings and a missing final newline.
+func hygieneFindings(fs analyze.FileStats) []finding {
+	var out []finding
+	if fs.EOL == "mixed" {
+		var counts []string
+		for _, c := range []struct {
+			n    int
+			name string
+		}{{fs.LF, "LF"}, {fs.CRLF, "CRLF"}, {fs.CR, "CR"}} {
+			if c.n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", c.n, c.name))
+			}
+		}
+		out = append(out, finding{Path: fs.Path, Check: "eol", Message: "mixed line endings (" + strings.Join(counts, ", ") + ")"})
+	}
+	if !fs.FinalNewline {
+		out = append(out, finding{Path: fs.Path, Check: "final-newline", Message: "no newline at end of file"})
+	}
+	return out
+}
+
+// lineLengthFindings reports every line longer than limit runes.
+func lineLengthFindings(fs analyze.FileStats, limit int) []finding {
+	out := make([]finding, 0, len(fs.LongLines))
+	for _, line := range fs.LongLines {
+		out = append(out, finding{Path: fs.Path, Line: line, Check: "line-length", Message: fmt.Sprintf("line longer than %d characters", limit)})
+	}
+	return out
+}
+
+// writeChecks runs the enabled checks over the text files in stats and
+// prints what they find, one problem per line like a compiler would. Files
+// that could not be read are reported as problems too. It returns
+// exitCheckFailed if anything was found.
+func writeChecks(stats []analyze.FileStats, cfg cli.Config) int {
+	newSorter(cfg).sortStats(stats)
+	var findings []finding
+	failed, checked := 0, 0
+	for _, fs := range stats {
+		if !fs.HasError && fs.Kind != "text" {
+			continue
+		}
+		checked++
+		var found []finding
+		switch {
+		case fs.HasError:
+			// A file that cannot be read cannot pass.
+			found = append(found, finding{Path: fs.Path, Check: "error", Message: "error: " + fs.ErrorText})
+		case cfg.CheckHygiene:
+			found = append(found, hygieneFindings(fs)...)
+		}
+		if !fs.HasError && cfg.MaxLineLength > 0 {
+			found = append(found, lineLengthFindings(fs, cfg.MaxLineLength)...)
+		}
+		if len(found) > 0 {
+			failed++
+			findings = append(findings, found...)
+		}
+	}
+
+	switch cfg.Format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []finding{}
+		}
+		if err := enc.Encode(findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			return 1
+		}
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if !cfg.NoHeader {
+			_ = w.Write([]string{"Path", "Line", "Check", "Message"})
+		}
+		for _, f := range findings {
+			line := ""
+			if f.Line > 0 {
+				line = strconv.Itoa(f.Line)
+			}
+			_ = w.Write([]string{f.Path, line, f.Check, f.Message})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
+		}
+	default:
+		for _, f := range findings {
+			if f.Line > 0 {
+				fmt.Printf("%s:%d: %s\n", f.Path, f.Line, f.Message)
+			} else {
+				fmt.Printf("%s: %s\n", f.Path, f.Message)
+			}
+		}
+	}
+
+	if failed == 0 {
+		return 0
+	}
+	noun := "problems"
+	if len(findings) == 1 {
+		noun = "problem"
+	}
+	fmt.Fprintf(os.Stderr, "aperio: %d %s in %d of %d files\n", len(findings), noun, failed, checked)
+	return exitCheckFailed
+}
@@ -0,0 +1,427 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/expr"
+	"github.com/ADJB1212/Aperio/internal/icons"
+	"github.com/ADJB1212/Aperio/internal/util"
+)
+
+// cellOpts carries the display settings cell formatters depend on.
+type cellOpts struct {
+	commas    bool
+	icons     bool
+	pathStyle string
+}
+
+// column is one per-file output column. Every writer draws its columns from
+// the registry below, so a new metric only needs an entry here.
+type column struct {
+	key    string
+	header string
+	// json is the field name used for JSON and ndjson output.
+	json string
+	// right marks numeric columns, right-aligned in tables.
+	right bool
+	// keepOnError shows the value on error rows instead of a placeholder.
+	keepOnError bool
+	// text formats the cell for the table, markdown and html writers.
+	text func(fs analyze.FileStats, o cellOpts) string
+	// raw formats the cell for CSV. Nil means the same as text without
+	// commas or icons.
+	raw func(fs analyze.FileStats, o cellOpts) string
+	// value is the typed value for JSON.
+	value func(fs analyze.FileStats) any
+	// where is the value --where expressions see. Nil means value.
+	where func(fs analyze.FileStats) any
+	// total formats the --sum footer cell; nil leaves it blank.
+	total func(t totals, o cellOpts) string
+}
+
+// cell returns the display text of c for fs.
+func (c column) cell(fs analyze.FileStats, o cellOpts) string {
+	if fs.HasError && !c.keepOnError {
+		return "-"
+	}
+	return c.text(fs, o)
+}
+
+// csvCell returns the CSV text of c for fs. Error rows leave metrics empty.
+func (c column) csvCell(fs analyze.FileStats, o cellOpts) string {
+	if c.key == "error" {
+		return fs.ErrorText
+	}
+	if fs.HasError && !c.keepOnError {
+		return ""
+	}
+	if c.raw != nil {
+		return c.raw(fs, o)
+	}
+	o.commas, o.icons = false, false
+	return c.text(fs, o)
+}
+
+// count builds a text-only count column: binary files show "-". sloc columns
+// also show "-" for files in no known language. A nil total marks a value
+// that does not add up across files: the --sum footer is left blank and the
+// --top others row shows "-".
+func count(key, header, json string, sloc bool, get func(analyze.FileStats) int, total func(totals) int) column {
+	c := column{
+		key:    key,
+		header: header,
+		json:   json,
+		right:  true,
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			switch {
+			case fs.Kind == "binary", fs.Kind == othersKind && total == nil:
+				return "-"
+			case sloc && fs.Language == "" && fs.Kind != othersKind:
+				return "-"
+			}
+			return formatInt(get(fs), o.commas)
+		},
+		value: func(fs analyze.FileStats) any { return get(fs) },
+	}
+	if total != nil {
+		c.total = func(t totals, o cellOpts) string { return formatInt(total(t), o.commas) }
+	}
+	return c
+}
+
+// mean builds a text-only average column with one decimal.
+func mean(key, header, json string, get func(analyze.FileStats) float64) column {
+	return column{
+		key:    key,
+		header: header,
+		json:   json,
+		right:  true,
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if fs.Kind == "binary" || fs.Kind == othersKind {
+				return "-"
+			}
+			return fmt.Sprintf("%.1f", get(fs))
+		},
+		value: func(fs analyze.FileStats) any { return get(fs) },
+	}
+}
+
+// flag builds a text-only yes/no column: binary files show "-".
+func flag(key, header, json string, get func(analyze.FileStats) bool) column {
+	return column{
+		key:    key,
+		header: header,
+		json:   json,
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			switch {
+			case fs.Kind == "binary" || fs.Kind == othersKind:
+				return "-"
+			case get(fs):
+				return "yes"
+			}
+			return "no"
+		},
+		raw:   func(fs analyze.FileStats, o cellOpts) string { return fmt.Sprint(get(fs)) },
+		value: func(fs analyze.FileStats) any { return get(fs) },
+	}
+}
+
+// proseCount and proseScore build prose metric columns: files without
+// prose metrics show "-".
+func proseCount(key, header, json string, get func(analyze.FileStats) int) column {
+	return column{
+		key:    key,
+		header: header,
+		json:   json,
+		right:  true,
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if !fs.Prose {
+				return "-"
+			}
+			return formatInt(get(fs), o.commas)
+		},
+		value: func(fs analyze.FileStats) any { return get(fs) },
+	}
+}
+
+func proseScore(key, header, json string, get func(analyze.FileStats) float64) column {
+	return column{
+		key:    key,
+		header: header,
+		json:   json,
+		right:  true,
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if !fs.Prose {
+				return "-"
+			}
+			return fmt.Sprintf("%.1f", get(fs))
+		},
+		value: func(fs analyze.FileStats) any { return get(fs) },
+	}
+}
+
+var columns = []column{
+	{
+		key: "name", header: "File", json: "Name", keepOnError: true,
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fileLabel(fs, o.pathStyle) },
+		value: func(fs analyze.FileStats) any { return fs.Name },
+		total: func(t totals, o cellOpts) string { return fmt.Sprintf("TOTAL (%d files)", t.Files) },
+	},
+	{
+		key: "path", header: "Path", json: "Path", keepOnError: true,
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.Path },
+		value: func(fs analyze.FileStats) any { return fs.Path },
+	},
+	{
+		key: "ext", header: "Ext", json: "Ext", keepOnError: true,
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if o.icons {
+				if ic := extIcon(fs); ic != "" {
+					return fs.Ext + " " + ic
+				}
+			}
+			return fs.Ext
+		},
+		value: func(fs analyze.FileStats) any { return fs.Ext },
+	},
+	{
+		key: "kind", header: "Kind", json: "Kind",
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.Kind },
+		value: func(fs analyze.FileStats) any { return fs.Kind },
+	},
+	{
+		key: "mime", header: "MIME", json: "MIME",
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.MIME },
+		value: func(fs analyze.FileStats) any { return fs.MIME },
+	},
+	{
+		key: "type", header: "Type", json: "Type",
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.Type },
+		value: func(fs analyze.FileStats) any { return fs.Type },
+	},
+	{
+		key: "encoding", header: "Encoding", json: "Encoding",
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.Encoding },
+		value: func(fs analyze.FileStats) any { return fs.Encoding },
+	},
+	{
+		key: "language", header: "Language", json: "Language",
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if fs.Language == "" {
+				return "-"
+			}
+			return fs.Language
+		},
+		raw:   func(fs analyze.FileStats, o cellOpts) string { return fs.Language },
+		value: func(fs analyze.FileStats) any { return fs.Language },
+	},
+	{
+		key: "bytes", header: "SizeBytes", json: "SizeBytes", right: true,
+		text:  func(fs analyze.FileStats, o cellOpts) string { return formatInt64(fs.SizeBytes, o.commas) },
+		value: func(fs analyze.FileStats) any { return fs.SizeBytes },
+		total: func(t totals, o cellOpts) string { return formatInt64(t.Bytes, o.commas) },
+	},
+	{
+		key: "size", header: "Size", json: "Size", right: true,
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.Size },
+		value: func(fs analyze.FileStats) any { return fs.Size },
+		where: func(fs analyze.FileStats) any { return fs.SizeBytes },
+		total: func(t totals, o cellOpts) string { return analyze.HumanBytes(t.Bytes) },
+	},
+	count("lines", "Lines", "Lines", false, func(fs analyze.FileStats) int { return fs.Lines }, func(t totals) int { return t.Lines }),
+	count("code", "Code", "CodeLines", true, func(fs analyze.FileStats) int { return fs.CodeLines }, func(t totals) int { return t.Code }),
+	count("comment", "Comment", "CommentLines", true, func(fs analyze.FileStats) int { return fs.CommentLines }, func(t totals) int { return t.Comment }),
+	count("blank", "Blank", "BlankLines", true, func(fs analyze.FileStats) int { return fs.BlankLines }, func(t totals) int { return t.Blank }),
+	count("words", "Words", "Words", false, func(fs analyze.FileStats) int { return fs.Words }, func(t totals) int { return t.Words }),
+	count("chars", "Chars", "Chars", false, func(fs analyze.FileStats) int { return fs.Chars }, func(t totals) int { return t.Chars }),
+	{
+		key: "eol", header: "EOL", json: "EOL",
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if fs.EOL == "" {
+				return "-"
+			}
+			return fs.EOL
+		},
+		raw:   func(fs analyze.FileStats, o cellOpts) string { return fs.EOL },
+		value: func(fs analyze.FileStats) any { return fs.EOL },
+	},
+	count("lf", "LF", "LF", false, func(fs analyze.FileStats) int { return fs.LF }, nil),
+	count("crlf", "CRLF", "CRLF", false, func(fs analyze.FileStats) int { return fs.CRLF }, nil),
+	count("cr", "CR", "CR", false, func(fs analyze.FileStats) int { return fs.CR }, nil),
+	flag("bom", "BOM", "HasBOM", func(fs analyze.FileStats) bool { return fs.HasBOM }),
+	flag("final_newline", "Final NL", "FinalNewline", func(fs analyze.FileStats) bool { return fs.FinalNewline }),
+	count("trailing_space", "Trailing WS", "TrailingSpaceLines", false, func(fs analyze.FileStats) int { return fs.TrailingSpaceLines }, nil),
+	count("line_max", "Max Len", "MaxLineLength", false, func(fs analyze.FileStats) int { return fs.MaxLineLength }, nil),
+	mean("line_mean", "Mean Len", "MeanLineLength", func(fs analyze.FileStats) float64 { return fs.MeanLineLength }),
+	count("line_p95", "P95 Len", "P95LineLength", false, func(fs analyze.FileStats) int { return fs.P95LineLength }, nil),
+	count("width_max", "Max Width", "MaxLineWidth", false, func(fs analyze.FileStats) int { return fs.MaxLineWidth }, nil),
+	mean("width_mean", "Mean Width", "MeanLineWidth", func(fs analyze.FileStats) float64 { return fs.MeanLineWidth }),
+	count("width_p95", "P95 Width", "P95LineWidth", false, func(fs analyze.FileStats) int { return fs.P95LineWidth }, nil),
+	count("longest_line", "Longest Line", "LongestLine", false, func(fs analyze.FileStats) int { return fs.LongestLine }, nil),
+	{
+		key: "indent", header: "Indent", json: "IndentStyle",
+		text: func(fs analyze.FileStats, o cellOpts) string {
+			if fs.IndentStyle == "" {
+				return "-"
+			}
+			return fs.IndentStyle
+		},
+		raw:   func(fs analyze.FileStats, o cellOpts) string { return fs.IndentStyle },
+		value: func(fs analyze.FileStats) any { return fs.IndentStyle },
+	},
+	count("indent_width", "Indent Width", "IndentWidth", false, func(fs analyze.FileStats) int { return fs.IndentWidth }, nil),
+	count("mixed_indent", "Mixed Indent", "MixedIndentLines", false, func(fs analyze.FileStats) int { return fs.MixedIndentLines }, nil),
+	count("indent_max", "Max Depth", "MaxIndentDepth", false, func(fs analyze.FileStats) int { return fs.MaxIndentDepth }, nil),
+	mean("indent_mean", "Mean Depth", "MeanIndentDepth", func(fs analyze.FileStats) float64 { return fs.MeanIndentDepth }),
+	flag("prose", "Prose", "Prose", func(fs analyze.FileStats) bool { return fs.Prose }),
+	proseCount("sentences", "Sentences", "Sentences", func(fs analyze.FileStats) int { return fs.Sentences }),
+	proseCount("paragraphs", "Paragraphs", "Paragraphs", func(fs analyze.FileStats) int { return fs.Paragraphs }),
+	proseCount("syllables", "Syllables", "Syllables", func(fs analyze.FileStats) int { return fs.Syllables }),
+	proseScore("reading_ease", "Ease", "ReadingEase", func(fs analyze.FileStats) float64 { return fs.ReadingEase }),
+	proseScore("grade_level", "Grade", "GradeLevel", func(fs analyze.FileStats) float64 { return fs.GradeLevel }),
+	proseScore("reading_time", "Read Min", "ReadingMinutes", func(fs analyze.FileStats) float64 { return fs.ReadingMinutes }),
+	{
+		key: "modified", header: "Modified", json: "ModTime", keepOnError: true,
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.ModTime },
+		value: func(fs analyze.FileStats) any { return fs.ModTime },
+		where: func(fs analyze.FileStats) any { return time.Unix(fs.ModUnix, 0) },
+	},
+	{
+		key: "hash", header: "Hash", json: "Hash",
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.Hash },
+		value: func(fs analyze.FileStats) any { return fs.Hash },
+	},
+	{
+		key: "error", header: "Error", json: "Error", keepOnError: true,
+		text:  func(fs analyze.FileStats, o cellOpts) string { return fs.ErrorText },
+		value: func(fs analyze.FileStats) any { return fs.ErrorText },
+	},
+}
+
+// Default column sets. CSV carries raw bytes and the error text as columns;
+// the table shows errors in place of the last column.
+var (
+	tableColumns = []string{"name", "ext", "kind", "type", "language", "size", "lines", "code", "comment", "blank", "words", "chars", "modified"}
+	csvColumns   = []string{"name", "ext", "kind", "mime", "type", "encoding", "language", "bytes", "size", "lines", "code", "comment", "blank", "words", "chars", "modified", "error"}
+	// proseColumns are added to the defaults with --prose.
+	proseColumns = []string{"sentences", "paragraphs", "reading_ease", "grade_level", "reading_time"}
+)
+
+// columnIndex maps column keys to registry entries.
+var columnIndex = func() map[string]column {
+	m := make(map[string]column, len(columns))
+	for _, c := range columns {
+		m[c.key] = c
+	}
+	return m
+}()
+
+// selectColumns resolves column keys (already validated) to registry entries.
+func selectColumns(keys []string) []column {
+	out := make([]column, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, columnIndex[k])
+	}
+	return out
+}
+
+// matchWhere evaluates a --where expression against fs, resolving fields
+// through the column registry.
+func matchWhere(p *expr.Program, fs analyze.FileStats) bool {
+	return p.Eval(func(name string) any {
+		c := columnIndex[name]
+		if c.where != nil {
+			return c.where(fs)
+		}
+		return c.value(fs)
+	})
+}
+
+// headers returns the column titles of cols.
+func headers(cols []column) []string {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = c.header
+	}
+	return out
+}
+
+// columnRecord marshals a file as a JSON object holding only the selected
+// columns, in order. A non-empty record is written first as "record".
+type columnRecord struct {
+	record string
+	fs     analyze.FileStats
+	cols   []column
+}
+
+func (r columnRecord) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	if r.record != "" {
+		fmt.Fprintf(&b, `"record":%q`, r.record)
+	}
+	for i, c := range r.cols {
+		if i > 0 || r.record != "" {
+			b.WriteByte(',')
+		}
+		v, err := json.Marshal(c.value(r.fs))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%q:", c.json)
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// extIcon returns the colored Nerd Fonts icon for a file, or "".
+func extIcon(fs analyze.FileStats) string {
+	ic := icons.Icon(fs.Name)
+	if ic == "" {
+		return ""
+	}
+	// Colorize icon based on extension (best-effort).
+	colorCode := func(ext string) int {
+		switch strings.ToLower(ext) {
+		case ".go":
+			return 51
+		case ".rs":
+			return 202
+		case ".js", ".jsx", ".mjs", ".cjs":
+			return 184
+		case ".ts", ".tsx":
+			return 27
+		case ".py":
+			return 220
+		case ".c", ".h", ".hpp", ".hh", ".hxx", ".cc", ".cpp", ".cxx":
+			return 27
+		case ".java", ".scala", ".swift", ".rb":
+			return 196
+		case ".kt", ".kts", ".php", ".hs":
+			return 129
+		case ".lua":
+			return 33
+		case ".html":
+			return 160
+		case ".cs", ".csx":
+			return 55
+		case ".css", ".scss", ".sass", ".less":
+			return 162
+		case ".json", ".yaml", ".yml", ".toml", ".ini":
+			return 242
+		case ".sh", ".bash", ".zsh", ".ksh", ".fish", ".vim":
+			return 41
+		case ".zig":
+			return 208
+		default:
+			return 244
+		}
+	}(fs.Ext)
+	return util.Colorize(ic, colorCode, -1)
+}
@@ -0,0 +1,294 @@
+package run
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/cli"
+	"github.com/ADJB1212/Aperio/internal/ui/progress"
+)
+
+// dupGroup is a set of files with identical content.
+type dupGroup struct {
+	hash  string
+	size  int64
+	files []analyze.FileStats
+}
+
+// wasted is the space every copy but one takes up.
+func (g dupGroup) wasted() int64 { return g.size * int64(len(g.files)-1) }
+
+// runDuplicates reports groups of identical files. Files are only stat'ed
+// during the walk; contents are hashed only when another file has the same
+// size, so unique sizes are never read.
+func runDuplicates(ctx, sigCtx context.Context, cfg cli.Config, jobs int) int {
+	var p pipeline
+	results := p.start(ctx, cfg, jobs, func(ctx context.Context, path string) (analyze.FileStats, error) {
+		return analyze.StatFile(path)
+	})
+
+	var bar *progress.Bar
+	if cfg.Progress {
+		bar = progress.New(os.Stderr, 40)
+		bar.SetLabel("scanning")
+		bar.Render(0, 0)
+	}
+	bySize := make(map[int64][]analyze.FileStats)
+	processed, skipped := 0, 0
+	for fs := range results {
+		processed++
+		if bar != nil {
+			bar.Render(processed, int(p.discovered.Load()))
+		}
+		switch {
+		case fs.HasError:
+			skipped++
+		case fs.SizeBytes > 0:
+			// Empty files are all identical; they are not reported.
+			bySize[fs.SizeBytes] = append(bySize[fs.SizeBytes], fs)
+		}
+	}
+
+	var candidates []analyze.FileStats
+	for _, files := range bySize {
+		if len(files) > 1 {
+			candidates = append(candidates, files...)
+		}
+	}
+	if bar != nil {
+		bar.SetLabel("hashing")
+		bar.Render(0, len(candidates))
+	}
+	hashed, failed := hashFiles(ctx, candidates, cfg.Hash, jobs, bar)
+	skipped += failed
+	interrupted := sigCtx.Err() != nil
+	if bar != nil {
+		if interrupted {
+			bar.SetLabel("interrupted")
+		}
+		bar.Finish()
+	}
+
+	if !interrupted && !stdinOK(cfg) {
+		return 1
+	}
+
+	groups := groupDuplicates(hashed, newSorter(cfg))
+	resolver := newPathResolver(cfg.PathStyle, cfg.RelativeTo)
+	for _, g := range groups {
+		for i := range g.files {
+			resolver.apply(&g.files[i])
+		}
+	}
+
+	code := writeDuplicates(groups, cfg)
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "aperio: skipped %d unreadable files\n", skipped)
+	}
+	if interrupted {
+		fmt.Fprintf(os.Stderr, "aperio: interrupted; results are partial (%d of %d discovered files)\n", processed, p.discovered.Load())
+		if code == 0 {
+			code = exitInterrupted
+		}
+	}
+	return code
+}
+
+// hashFiles hashes files on jobs workers and returns those that could be
+// read, with Hash set, plus the number that could not.
+func hashFiles(ctx context.Context, files []analyze.FileStats, algo string, jobs int, bar *progress.Bar) ([]analyze.FileStats, int) {
+	in := make(chan analyze.FileStats)
+	out := make(chan analyze.FileStats, jobs)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fs := range in {
+				h, err := analyze.HashFile(ctx, fs.Path, algo, analyze.Options{})
+				if err != nil {
+					fs.HasError, fs.ErrorText = true, err.Error()
+				}
+				fs.Hash = h
+				out <- fs
+			}
+		}()
+	}
+	go func() {
+		defer close(in)
+		for _, fs := range files {
+			select {
+			case in <- fs:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	var hashed []analyze.FileStats
+	done, failed := 0, 0
+	for fs := range out {
+		done++
+		if bar != nil {
+			bar.Render(done, len(files))
+		}
+		switch {
+		case fs.HasError && ctx.Err() == nil:
+			failed++
+		case !fs.HasError:
+			hashed = append(hashed, fs)
+		}
+	}
+	return hashed, failed
+}
+
+// groupDuplicates groups hashed files by size and hash, keeping groups of
+// two or more. Groups are ordered by wasted bytes, largest first; files
+// within a group follow the --sort keys.
+func groupDuplicates(files []analyze.FileStats, s sorter) []dupGroup {
+	type key struct {
+		size int64
+		hash string
+	}
+	index := make(map[key]int)
+	var groups []dupGroup
+	for _, fs := range files {
+		k := key{fs.SizeBytes, fs.Hash}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, dupGroup{hash: fs.Hash, size: fs.SizeBytes})
+		}
+		groups[i].files = append(groups[i].files, fs)
+	}
+	groups = slices.DeleteFunc(groups, func(g dupGroup) bool { return len(g.files) < 2 })
+	for _, g := range groups {
+		s.sortStats(g.files)
+	}
+	slices.SortFunc(groups, func(a, b dupGroup) int {
+		if c := cmp.Compare(b.wasted(), a.wasted()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.files[0].Path, b.files[0].Path)
+	})
+	return groups
+}
+
+func writeDuplicates(groups []dupGroup, cfg cli.Config) int {
+	switch cfg.Format {
+	case "json":
+		if err := writeDuplicateJSON(groups); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			return 1
+		}
+	case "csv":
+		if err := writeDuplicateCSV(groups, !cfg.NoHeader); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
+		}
+	case "markdown":
+		writeMarkdown(os.Stdout, buildDuplicateTable(groups, cfg))
+	default: // table
+		t := buildDuplicateTable(groups, cfg)
+		renderTable(os.Stdout, t.headers, t.rows, t.footer, t.rightAligned, cfg.Plain)
+	}
+	return 0
+}
+
+// buildDuplicateTable lays out one row per group. The hash is shortened;
+// CSV and JSON carry it in full.
+func buildDuplicateTable(groups []dupGroup, cfg cli.Config) tableData {
+	t := tableData{
+		headers:      []string{"Copies", "Size", "Wasted", "Hash", "Files"},
+		rightAligned: map[int]bool{0: true, 1: true, 2: true},
+	}
+	var files int
+	var wasted int64
+	for _, g := range groups {
+		// Copies usually share a base name, so the Files column always shows
+		// paths: as given, or as --path-style and --relative-to resolve them.
+		names := make([]string, len(g.files))
+		for i, fs := range g.files {
+			names[i] = fs.Path
+		}
+		t.rows = append(t.rows, []string{
+			formatInt(len(g.files), cfg.Commas),
+			analyze.HumanBytes(g.size),
+			analyze.HumanBytes(g.wasted()),
+			g.hash[:min(len(g.hash), 12)],
+			strings.Join(names, ", "),
+		})
+		files += len(g.files)
+		wasted += g.wasted()
+	}
+	if cfg.ShowSum {
+		t.footer = []string{formatInt(files, cfg.Commas), "", analyze.HumanBytes(wasted), "", fmt.Sprintf("TOTAL (%d groups)", len(groups))}
+	}
+	return t
+}
+
+// writeDuplicateCSV writes one row per file, so the output can drive scripts;
+// Group numbers the groups from 1.
+func writeDuplicateCSV(groups []dupGroup, header bool) error {
+	w := csv.NewWriter(os.Stdout)
+	if header {
+		_ = w.Write([]string{"Group", "Hash", "SizeBytes", "WastedBytes", "Path"})
+	}
+	for i, g := range groups {
+		for _, fs := range g.files {
+			_ = w.Write([]string{
+				fmt.Sprint(i + 1),
+				g.hash,
+				fmt.Sprint(g.size),
+				fmt.Sprint(g.wasted()),
+				fs.Path,
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeDuplicateJSON(groups []dupGroup) error {
+	type jsonGroup struct {
+		Hash        string
+		SizeBytes   int64
+		Size        string
+		Copies      int
+		WastedBytes int64
+		Wasted      string
+		Paths       []string
+	}
+	out := make([]jsonGroup, 0, len(groups))
+	for _, g := range groups {
+		paths := make([]string, len(g.files))
+		for i, fs := range g.files {
+			paths[i] = fs.Path
+		}
+		out = append(out, jsonGroup{
+			Hash:        g.hash,
+			SizeBytes:   g.size,
+			Size:        analyze.HumanBytes(g.size),
+			Copies:      len(g.files),
+			WastedBytes: g.wasted(),
+			Wasted:      analyze.HumanBytes(g.wasted()),
+			Paths:       paths,
+		})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,403 @@
+package run
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+)
+
+// totals aggregates file stats for the --sum footer and --group-by rows.
+// Files counts every file, ReadFiles those that could be read. Sizes cover
+// the readable files; text counts skip binary files.
+type totals struct {
+	Files     int
+	ReadFiles int
+	TextFiles int
+	Bytes     int64
+	Lines     int
+	Code      int
+	Comment   int
+	Blank     int
+	Words     int
+	Chars     int
+	MaxBytes  int64
+	MaxLines  int
+	MaxWords  int
+	MaxChars  int
+}
+
+func (t *totals) add(fs analyze.FileStats) {
+	t.Files++
+	if fs.HasError {
+		return
+	}
+	t.ReadFiles++
+	t.Bytes += fs.SizeBytes
+	t.MaxBytes = max(t.MaxBytes, fs.SizeBytes)
+	if fs.Kind == "binary" {
+		return
+	}
+	t.TextFiles++
+	t.Lines += fs.Lines
+	t.Code += fs.CodeLines
+	t.Comment += fs.CommentLines
+	t.Blank += fs.BlankLines
+	t.Words += fs.Words
+	t.Chars += fs.Chars
+	t.MaxLines = max(t.MaxLines, fs.Lines)
+	t.MaxWords = max(t.MaxWords, fs.Words)
+	t.MaxChars = max(t.MaxChars, fs.Chars)
+}
+
+// merge folds another aggregate into t.
+func (t *totals) merge(o totals) {
+	t.Files += o.Files
+	t.ReadFiles += o.ReadFiles
+	t.TextFiles += o.TextFiles
+	t.Bytes += o.Bytes
+	t.Lines += o.Lines
+	t.Code += o.Code
+	t.Comment += o.Comment
+	t.Blank += o.Blank
+	t.Words += o.Words
+	t.Chars += o.Chars
+	t.MaxBytes = max(t.MaxBytes, o.MaxBytes)
+	t.MaxLines = max(t.MaxLines, o.MaxLines)
+	t.MaxWords = max(t.MaxWords, o.MaxWords)
+	t.MaxChars = max(t.MaxChars, o.MaxChars)
+}
+
+// MeanBytes is the average size per readable file.
+func (t totals) MeanBytes() int64 {
+	if n := int64(t.ReadFiles); n > 0 {
+		return t.Bytes / n
+	}
+	return 0
+}
+
+// MeanLines, MeanWords and MeanChars are averages over text files.
+func (t totals) MeanLines() float64 { return t.textMean(t.Lines) }
+func (t totals) MeanWords() float64 { return t.textMean(t.Words) }
+func (t totals) MeanChars() float64 { return t.textMean(t.Chars) }
+
+func (t totals) textMean(sum int) float64 {
+	if t.TextFiles > 0 {
+		return float64(sum) / float64(t.TextFiles)
+	}
+	return 0
+}
+
+// groupKeys maps --group-by keys to the value a file is grouped under.
+var groupKeys = map[string]func(analyze.FileStats) string{
+	"ext": func(fs analyze.FileStats) string {
+		if fs.Ext == "" {
+			return "(none)"
+		}
+		return strings.ToLower(fs.Ext)
+	},
+	"language": func(fs analyze.FileStats) string {
+		if fs.Language == "" {
+			return "(unknown)"
+		}
+		return fs.Language
+	},
+	"dir": func(fs analyze.FileStats) string {
+		return filepath.Dir(fs.Path)
+	},
+	"topdir": func(fs analyze.FileStats) string {
+		dir := filepath.ToSlash(filepath.Dir(fs.Path))
+		root := ""
+		if strings.HasPrefix(dir, "/") {
+			// Keep the root for absolute paths: /src/a -> /src
+			root, dir = "/", dir[1:]
+		}
+		if i := strings.IndexByte(dir, '/'); i >= 0 {
+			dir = dir[:i]
+		}
+		return filepath.FromSlash(root + dir)
+	},
+	"kind": func(fs analyze.FileStats) string {
+		if fs.HasError {
+			return "error"
+		}
+		return fs.Kind
+	},
+	"mime": func(fs analyze.FileStats) string {
+		if fs.HasError {
+			return "error"
+		}
+		return fs.MIME
+	},
+	"type": func(fs analyze.FileStats) string {
+		if fs.HasError {
+			return "error"
+		}
+		return fs.Type
+	},
+	"encoding": func(fs analyze.FileStats) string {
+		switch {
+		case fs.HasError:
+			return "error"
+		case fs.Encoding == "":
+			return "(binary)"
+		}
+		return fs.Encoding
+	},
+	"eol": func(fs analyze.FileStats) string {
+		switch {
+		case fs.HasError:
+			return "error"
+		case fs.EOL == "":
+			return "(binary)"
+		}
+		return fs.EOL
+	},
+	"indent": func(fs analyze.FileStats) string {
+		switch {
+		case fs.HasError:
+			return "error"
+		case fs.IndentStyle == "":
+			return "(binary)"
+		}
+		return fs.IndentStyle
+	},
+}
+
+// group is one aggregate row of a --group-by report.
+type group struct {
+	Keys []string
+	totals
+	// folded is the number of groups merged into a --top "others" row.
+	folded int
+}
+
+// groupStats collapses stats into one row per distinct combination of keys.
+func groupStats(stats []analyze.FileStats, keys []string) []group {
+	index := make(map[string]int)
+	var groups []group
+	for _, fs := range stats {
+		vals := make([]string, len(keys))
+		for i, k := range keys {
+			vals[i] = groupKeys[k](fs)
+		}
+		id := strings.Join(vals, "\x00")
+		i, ok := index[id]
+		if !ok {
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, group{Keys: vals})
+		}
+		groups[i].add(fs)
+	}
+	return groups
+}
+
+// sortGroups orders groups by the --sort keys that apply to aggregates
+// (size, lines, code, comment, blank, words, chars); any other key orders
+// by group key, which is also the final tie-breaker.
+func sortGroups(groups []group, s sorter) {
+	byKey := func(a, b group) int {
+		for i := range a.Keys {
+			if c := s.text(a.Keys[i], b.Keys[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	slices.SortStableFunc(groups, func(a, b group) int {
+		for _, k := range s.keys {
+			var c int
+			switch k.Key {
+			case "size":
+				c = cmp.Compare(a.Bytes, b.Bytes)
+			case "lines":
+				c = cmp.Compare(a.Lines, b.Lines)
+			case "code":
+				c = cmp.Compare(a.Code, b.Code)
+			case "comment":
+				c = cmp.Compare(a.Comment, b.Comment)
+			case "blank":
+				c = cmp.Compare(a.Blank, b.Blank)
+			case "words":
+				c = cmp.Compare(a.Words, b.Words)
+			case "chars":
+				c = cmp.Compare(a.Chars, b.Chars)
+			default:
+				c = byKey(a, b)
+			}
+			if k.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return byKey(a, b)
+	})
+}
+
+// groupHeaders returns the column titles for a report grouped by keys.
+func groupHeaders(keys []string) []string {
+	headers := make([]string, 0, len(keys)+16)
+	for _, k := range keys {
+		if c, ok := columnIndex[k]; ok {
+			headers = append(headers, c.header)
+		} else {
+			headers = append(headers, strings.ToUpper(k[:1])+k[1:])
+		}
+	}
+	return append(headers, "Files", "Size", "Lines", "Code", "Comment", "Blank", "Words", "Chars",
+		"Mean Size", "Max Size", "Mean Lines", "Max Lines", "Mean Words", "Max Words", "Mean Chars", "Max Chars")
+}
+
+func writeGroupTable(groups []group, keys []string, showSum bool, plain bool, commas bool) {
+	t := buildGroupTable(groups, keys, showSum, commas)
+	renderTable(os.Stdout, t.headers, t.rows, t.footer, t.rightAligned, plain)
+}
+
+// buildGroupTable formats group rows for display.
+func buildGroupTable(groups []group, keys []string, showSum bool, commas bool) tableData {
+	fmtInt := func(n int) string { return formatInt(n, commas) }
+	row := func(label []string, t totals) []string {
+		return append(label,
+			fmtInt(t.Files),
+			analyze.HumanBytes(t.Bytes),
+			fmtInt(t.Lines),
+			fmtInt(t.Code),
+			fmtInt(t.Comment),
+			fmtInt(t.Blank),
+			fmtInt(t.Words),
+			fmtInt(t.Chars),
+			analyze.HumanBytes(t.MeanBytes()),
+			analyze.HumanBytes(t.MaxBytes),
+			fmt.Sprintf("%.1f", t.MeanLines()),
+			fmtInt(t.MaxLines),
+			fmt.Sprintf("%.1f", t.MeanWords()),
+			fmtInt(t.MaxWords),
+			fmt.Sprintf("%.1f", t.MeanChars()),
+			fmtInt(t.MaxChars),
+		)
+	}
+
+	headers := groupHeaders(keys)
+	rightAligned := make(map[int]bool)
+	for i := len(keys); i < len(headers); i++ {
+		rightAligned[i] = true
+	}
+
+	var rows [][]string
+	var sum totals
+	count := 0
+	for _, g := range groups {
+		rows = append(rows, row(append([]string(nil), g.Keys...), g.totals))
+		sum.merge(g.totals)
+		count += max(g.folded, 1)
+	}
+
+	var footer []string
+	if showSum {
+		label := make([]string, len(keys))
+		label[0] = fmt.Sprintf("TOTAL (%d groups)", count)
+		footer = row(label, sum)
+	}
+	return tableData{headers: headers, rows: rows, footer: footer, rightAligned: rightAligned}
+}
+
+func writeGroupCSV(groups []group, keys []string, header bool) error {
+	w := csv.NewWriter(os.Stdout)
+	if header {
+		headers := groupHeaders(keys)
+		// Machine-readable sizes are raw bytes.
+		for i, h := range headers {
+			headers[i] = strings.ReplaceAll(h, " ", "")
+			if strings.HasSuffix(headers[i], "Size") {
+				headers[i] += "Bytes"
+			}
+		}
+		_ = w.Write(headers)
+	}
+	for _, g := range groups {
+		_ = w.Write(append(append([]string(nil), g.Keys...),
+			fmt.Sprintf("%d", g.Files),
+			fmt.Sprintf("%d", g.Bytes),
+			fmt.Sprintf("%d", g.Lines),
+			fmt.Sprintf("%d", g.Code),
+			fmt.Sprintf("%d", g.Comment),
+			fmt.Sprintf("%d", g.Blank),
+			fmt.Sprintf("%d", g.Words),
+			fmt.Sprintf("%d", g.Chars),
+			fmt.Sprintf("%d", g.MeanBytes()),
+			fmt.Sprintf("%d", g.MaxBytes),
+			fmt.Sprintf("%.1f", g.MeanLines()),
+			fmt.Sprintf("%d", g.MaxLines),
+			fmt.Sprintf("%.1f", g.MeanWords()),
+			fmt.Sprintf("%d", g.MaxWords),
+			fmt.Sprintf("%.1f", g.MeanChars()),
+			fmt.Sprintf("%d", g.MaxChars),
+		))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// groupJSON is the JSON shape of one --group-by row.
+type groupJSON struct {
+	Group         map[string]string
+	Files         int
+	SizeBytes     int64
+	Size          string
+	Lines         int
+	CodeLines     int
+	CommentLines  int
+	BlankLines    int
+	Words         int
+	Chars         int
+	MeanSizeBytes int64
+	MaxSizeBytes  int64
+	MeanLines     float64
+	MaxLines      int
+	MeanWords     float64
+	MaxWords      int
+	MeanChars     float64
+	MaxChars      int
+}
+
+func writeGroupJSON(groups []group, keys []string) error {
+	out := make([]groupJSON, 0, len(groups))
+	for _, g := range groups {
+		key := make(map[string]string, len(keys))
+		for i, k := range keys {
+			key[k] = g.Keys[i]
+		}
+		out = append(out, groupJSON{
+			Group:         key,
+			Files:         g.Files,
+			SizeBytes:     g.Bytes,
+			Size:          analyze.HumanBytes(g.Bytes),
+			Lines:         g.Lines,
+			CodeLines:     g.Code,
+			CommentLines:  g.Comment,
+			BlankLines:    g.Blank,
+			Words:         g.Words,
+			Chars:         g.Chars,
+			MeanSizeBytes: g.MeanBytes(),
+			MaxSizeBytes:  g.MaxBytes,
+			MeanLines:     g.MeanLines(),
+			MaxLines:      g.MaxLines,
+			MeanWords:     g.MeanWords(),
+			MaxWords:      g.MaxWords,
+			MeanChars:     g.MeanChars(),
+			MaxChars:      g.MaxChars,
+		})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
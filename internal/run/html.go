@@ -0,0 +1,199 @@
+package run
+
+import (
+	"html/template"
+	"io"
+	"sort"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+)
+
+// chartLimit caps the bars per chart; smaller extensions are folded into one.
+const chartLimit = 12
+
+// barSpan is the pixel width of the longest bar; labels and values sit on
+// either side of it.
+const barSpan = 220
+
+// chart is a horizontal bar chart of one total per extension.
+type chart struct {
+	Title  string
+	Height int
+	Bars   []bar
+}
+
+type bar struct {
+	Label string
+	Value string
+	Y     int
+	Width float64
+}
+
+// htmlReport is the data behind htmlPage.
+type htmlReport struct {
+	Title        string
+	Headers      []string
+	Rows         [][]string
+	Footer       []string
+	RightAligned map[int]bool
+	Charts       []chart
+}
+
+// writeHTML renders t as a self-contained page with sortable columns and
+// per-extension charts built from stats.
+func writeHTML(out io.Writer, t tableData, stats []analyze.FileStats) error {
+	byExt := groupStats(stats, []string{"ext"})
+	report := htmlReport{
+		Title:        "Aperio report",
+		Headers:      t.headers,
+		Rows:         stripRows(t.rows),
+		RightAligned: t.rightAligned,
+		Charts: []chart{
+			newChart("Size by extension", byExt, func(t totals) (float64, string) {
+				return float64(t.Bytes), analyze.HumanBytes(t.Bytes)
+			}),
+			newChart("Lines by extension", byExt, func(t totals) (float64, string) {
+				return float64(t.Lines), formatInt(t.Lines, true)
+			}),
+			newChart("Files by extension", byExt, func(t totals) (float64, string) {
+				return float64(t.Files), formatInt(t.Files, true)
+			}),
+		},
+	}
+	if t.footer != nil {
+		report.Footer = stripRows([][]string{t.footer})[0]
+	}
+	return htmlPage.Execute(out, report)
+}
+
+func stripRows(rows [][]string) [][]string {
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		out[i] = make([]string, len(row))
+		for j, cell := range row {
+			out[i][j] = stripANSI(cell)
+		}
+	}
+	return out
+}
+
+// newChart orders groups by the charted value and scales bars to the largest.
+func newChart(title string, groups []group, value func(totals) (float64, string)) chart {
+	groups = append([]group(nil), groups...)
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, _ := value(groups[i].totals)
+		b, _ := value(groups[j].totals)
+		return a > b
+	})
+	if len(groups) > chartLimit {
+		other := group{Keys: []string{"(other)"}}
+		for _, g := range groups[chartLimit-1:] {
+			other.merge(g.totals)
+		}
+		groups = append(groups[:chartLimit-1], other)
+	}
+
+	c := chart{Title: title}
+	var top float64
+	for _, g := range groups {
+		v, _ := value(g.totals)
+		top = max(top, v)
+	}
+	for i, g := range groups {
+		v, label := value(g.totals)
+		w := 0.0
+		if top > 0 {
+			w = v / top * barSpan
+		}
+		c.Bars = append(c.Bars, bar{Label: g.Keys[0], Value: label, Y: i * 22, Width: w})
+	}
+	c.Height = len(c.Bars)*22 + 4
+	return c
+}
+
+var htmlPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 10px; border-bottom: 1px solid #ddd; white-space: nowrap; }
+th { cursor: pointer; background: #f4f4f4; user-select: none; }
+th[aria-sort=ascending]::after { content: " \25B2"; }
+th[aria-sort=descending]::after { content: " \25BC"; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+tfoot td { font-weight: bold; border-top: 2px solid #999; }
+.charts { display: flex; flex-wrap: wrap; gap: 2em; margin-bottom: 2em; }
+.charts h2 { font-size: 1em; margin: 0 0 .5em; }
+.charts svg { width: 420px; }
+.charts rect { fill: #4a7fbf; }
+.charts text { font-size: 12px; fill: #222; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="charts">
+{{- range .Charts}}
+<figure>
+<h2>{{.Title}}</h2>
+<svg viewBox="0 0 420 {{.Height}}" height="{{.Height}}" role="img" aria-label="{{.Title}}">
+{{- range .Bars}}
+<text x="96" y="{{.Y}}" dy="15" text-anchor="end">{{.Label}}</text>
+<rect x="100" y="{{.Y}}" height="18" width="{{printf "%.1f" .Width}}"></rect>
+<text x="410" y="{{.Y}}" dy="15" text-anchor="end">{{.Value}}</text>
+{{- end}}
+</svg>
+</figure>
+{{- end}}
+</div>
+<table id="stats">
+<thead><tr>
+{{- range $i, $h := .Headers}}<th{{if index $.RightAligned $i}} class="num"{{end}}>{{$h}}</th>{{end -}}
+</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range $i, $c := .}}<td{{if index $.RightAligned $i}} class="num"{{end}}>{{$c}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+{{- if .Footer}}
+<tfoot><tr>{{range $i, $c := .Footer}}<td{{if index $.RightAligned $i}} class="num"{{end}}>{{$c}}</td>{{end}}</tr></tfoot>
+{{- end}}
+</table>
+<script>
+(function () {
+  var units = { B: 1, KiB: 1024, MiB: 1048576, GiB: 1073741824, TiB: 1099511627776 };
+  // Cells are display strings: sort sizes by bytes and counts numerically.
+  function key(text) {
+    var m = /^([\d.]+) (B|KiB|MiB|GiB|TiB)$/.exec(text);
+    if (m) return parseFloat(m[1]) * units[m[2]];
+    var n = text.replace(/,/g, "");
+    if (n !== "" && !isNaN(n)) return parseFloat(n);
+    return null;
+  }
+  var table = document.getElementById("stats");
+  var body = table.tBodies[0];
+  var heads = table.tHead.rows[0].cells;
+  Array.prototype.forEach.call(heads, function (th, col) {
+    th.addEventListener("click", function () {
+      var asc = th.getAttribute("aria-sort") !== "ascending";
+      Array.prototype.forEach.call(heads, function (h) { h.removeAttribute("aria-sort"); });
+      th.setAttribute("aria-sort", asc ? "ascending" : "descending");
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[col].textContent, y = b.cells[col].textContent;
+        var kx = key(x), ky = key(y), c;
+        if (kx !== null && ky !== null) c = kx - ky;
+        else if (kx !== null || ky !== null) return kx === null ? 1 : -1; // "-" last
+        else c = x.localeCompare(y);
+        return asc ? c : -c;
+      });
+      rows.forEach(function (r) { body.appendChild(r); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,48 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeMarkdown renders t as a GitHub-flavored pipe table. Numeric columns
+// are right-aligned as in the terminal table; the totals row is bold.
+func writeMarkdown(out io.Writer, t tableData) {
+	line := func(cells []string, bold bool) {
+		var b strings.Builder
+		b.WriteString("|")
+		for i := range t.headers {
+			cell := ""
+			if i < len(cells) {
+				cell = mdEscape(stripANSI(cells[i]))
+			}
+			if bold && cell != "" {
+				cell = "**" + cell + "**"
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		fmt.Fprintln(out, b.String())
+	}
+
+	line(t.headers, false)
+	align := make([]string, len(t.headers))
+	for i := range t.headers {
+		align[i] = "---"
+		if t.rightAligned[i] {
+			align[i] = "---:"
+		}
+	}
+	fmt.Fprintln(out, "| "+strings.Join(align, " | ")+" |")
+	for _, row := range t.rows {
+		line(row, false)
+	}
+	if t.footer != nil {
+		line(t.footer, true)
+	}
+}
+
+// mdEscaper keeps cell text from breaking the table or being read as markup.
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;")
+
+func mdEscape(s string) string { return mdEscaper.Replace(s) }
@@ -0,0 +1,235 @@
+package run
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/util"
+	"github.com/ADJB1212/Aperio/internal/width"
+)
+
+// writeJSON writes stats as one indented array. Without a --columns
+// selection (cols == nil) every FileStats field is included.
+func writeJSON(stats []analyze.FileStats, cols []column) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if cols == nil {
+		return enc.Encode(stats)
+	}
+	out := make([]columnRecord, len(stats))
+	for i, fs := range stats {
+		out[i] = columnRecord{fs: fs, cols: cols}
+	}
+	return enc.Encode(out)
+}
+
+func writeCSV(stats []analyze.FileStats, cols []column, header bool, o cellOpts) error {
+	w := csv.NewWriter(os.Stdout)
+	if header {
+		_ = w.Write(headers(cols))
+	}
+	for _, fs := range stats {
+		_ = w.Write(csvRecord(fs, cols, o))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvRecord formats one file as a CSV row.
+func csvRecord(fs analyze.FileStats, cols []column, o cellOpts) []string {
+	row := make([]string, len(cols))
+	for i, c := range cols {
+		row[i] = c.csvCell(fs, o)
+	}
+	return row
+}
+
+// tableData is the column model shared by the table, markdown and html
+// writers: display cells plus which columns hold numbers.
+type tableData struct {
+	headers      []string
+	rows         [][]string
+	footer       []string
+	rightAligned map[int]bool
+}
+
+func writeTable(stats []analyze.FileStats, cols []column, sum *totals, plain bool, o cellOpts) {
+	t := buildTable(stats, cols, sum, o)
+	renderTable(os.Stdout, t.headers, t.rows, t.footer, t.rightAligned, plain)
+}
+
+// buildTable formats per-file rows for display. A non-nil sum adds a totals
+// footer; it is passed in because --top rows no longer cover every file.
+func buildTable(stats []analyze.FileStats, cols []column, sum *totals, o cellOpts) tableData {
+	t := tableData{headers: headers(cols), rightAligned: make(map[int]bool)}
+	errCol := -1
+	for i, c := range cols {
+		t.rightAligned[i] = c.right
+		if c.key == "error" {
+			errCol = i
+		}
+	}
+
+	for _, fs := range stats {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = c.cell(fs, o)
+		}
+		if fs.HasError && errCol < 0 && len(row) > 0 {
+			// Without an Error column the message replaces the last cell.
+			row[len(row)-1] = fs.ErrorText
+		}
+		t.rows = append(t.rows, row)
+	}
+
+	// optional footer
+	if sum != nil {
+		t.footer = make([]string, len(cols))
+		for i, c := range cols {
+			if c.total != nil {
+				t.footer[i] = c.total(*sum, o)
+			}
+		}
+		if len(cols) > 0 && cols[0].total == nil {
+			t.footer[0] = fmt.Sprintf("TOTAL (%d files)", sum.Files)
+		}
+	}
+	return t
+}
+
+// renderTable draws rows under headers with box borders (ASCII when plain).
+// A non-nil footer is drawn after a separator as a totals row.
+func renderTable(out io.Writer, headers []string, rows [][]string, footer []string, rightAligned map[int]bool, plain bool) {
+	// compute column widths
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = displayWidth(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	if footer != nil {
+		for i, cell := range footer {
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	// drawing characters
+	vert := "│"
+	horiz := "─"
+	topLeft, topSep, topRight := "╭", "┬", "╮"
+	midLeft, midSep, midRight := "├", "┼", "┤"
+	botLeft, botSep, botRight := "╰", "┴", "╯"
+	if plain {
+		vert = "|"
+		horiz = "-"
+		topLeft, topSep, topRight = "+", "+", "+"
+		midLeft, midSep, midRight = "+", "+", "+"
+		botLeft, botSep, botRight = "+", "+", "+"
+	}
+
+	// draw line helpers
+	drawLine := func(left, sep, right string) {
+		var b strings.Builder
+		b.WriteString(left)
+		for i, w := range widths {
+			b.WriteString(strings.Repeat(horiz, w+2))
+			if i < len(widths)-1 {
+				b.WriteString(sep)
+			}
+		}
+		b.WriteString(right)
+		fmt.Fprintln(out, b.String())
+	}
+	// draw row helper
+	drawRow := func(cells []string) {
+		var b strings.Builder
+		b.WriteString(vert)
+		for i, w := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			if rightAligned[i] {
+				b.WriteString(" " + padLeft(cell, w) + " ")
+			} else {
+				b.WriteString(" " + padRight(cell, w) + " ")
+			}
+			if i < len(widths)-1 {
+				b.WriteString(vert)
+			}
+		}
+		b.WriteString(vert)
+		fmt.Fprintln(out, b.String())
+	}
+
+	// render table
+	drawLine(topLeft, topSep, topRight)
+	drawRow(headers)
+	drawLine(midLeft, midSep, midRight)
+	for i, row := range rows {
+		drawRow(row)
+		if i < len(rows)-1 {
+			drawLine(midLeft, midSep, midRight)
+		}
+	}
+	if footer != nil {
+		if len(rows) > 0 {
+			drawLine(midLeft, midSep, midRight)
+		}
+		drawRow(footer)
+	}
+	drawLine(botLeft, botSep, botRight)
+}
+
+var reANSI = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripANSI(s string) string { return reANSI.ReplaceAllString(s, "") }
+
+// displayWidth is the number of terminal cells s takes, ignoring colors.
+func displayWidth(s string) int {
+	return width.String(stripANSI(s))
+}
+
+func padRight(s string, width int) string {
+	pad := width - displayWidth(s)
+	if pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+func padLeft(s string, width int) string {
+	pad := width - displayWidth(s)
+	if pad > 0 {
+		return strings.Repeat(" ", pad) + s
+	}
+	return s
+}
+
+func formatInt(n int, commas bool) string {
+	if commas {
+		return util.CommaInt(n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatInt64(n int64, commas bool) string {
+	if commas {
+		return util.CommaInt64(n)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,52 @@
+package run
+
+import (
+	"path/filepath"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+)
+
+// pathResolver rewrites FileStats.Path according to --path-style and --relative-to.
+type pathResolver struct {
+	style string
+	// base is the absolute directory paths are made relative to; empty keeps
+	// paths as given.
+	base string
+}
+
+func newPathResolver(style, relativeTo string) pathResolver {
+	r := pathResolver{style: style}
+	if style == "relative" || relativeTo != "" {
+		dir := relativeTo
+		if dir == "" {
+			dir = "."
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			r.base = abs
+		}
+	}
+	return r
+}
+
+func (r pathResolver) apply(fs *analyze.FileStats) {
+	abs, err := filepath.Abs(fs.Path)
+	if err != nil {
+		return
+	}
+	switch {
+	case r.style == "absolute":
+		fs.Path = abs
+	case r.base != "":
+		if rel, err := filepath.Rel(r.base, abs); err == nil {
+			fs.Path = rel
+		}
+	}
+}
+
+// fileLabel returns what the File column shows for fs under the given path style.
+func fileLabel(fs analyze.FileStats, style string) string {
+	if style == "base" || fs.Path == "" {
+		return fs.Name
+	}
+	return fs.Path
+}
@@ -0,0 +1,90 @@
+package run
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/cli"
+	"github.com/ADJB1212/Aperio/internal/filter"
+	"github.com/ADJB1212/Aperio/internal/walk"
+)
+
+// pipeline discovers files and analyzes them on a fixed pool of workers.
+// Paths flow through small buffered channels, so memory use does not grow
+// with the number of files.
+type pipeline struct {
+	// discovered counts paths handed to the workers so far.
+	discovered atomic.Int64
+}
+
+// analyzeFunc produces the stats for one discovered path.
+type analyzeFunc func(ctx context.Context, path string) (analyze.FileStats, error)
+
+// start launches discovery and jobs workers running work. The returned
+// channel is closed once every discovered file has been processed or ctx is
+// canceled.
+func (p *pipeline) start(ctx context.Context, cfg cli.Config, jobs int, work analyzeFunc) <-chan analyze.FileStats {
+	walkOpts := walk.Options{
+		Recursive:      cfg.Recursive,
+		MaxDepth:       cfg.MaxDepth,
+		FollowSymlinks: cfg.Follow,
+		OneFileSystem:  cfg.OneFS,
+		Ignore:         !cfg.NoIgnore,
+		Hidden:         cfg.Hidden,
+	}
+	fileFilter := &filter.Filter{
+		Include:   cfg.Include,
+		Exclude:   cfg.Exclude,
+		MinSize:   cfg.MinSize,
+		MaxSize:   cfg.MaxSize,
+		NewerThan: cfg.NewerThan,
+		OlderThan: cfg.OlderThan,
+		Kind:      cfg.Kind,
+	}
+	if fileFilter.Active() {
+		walkOpts.Filter = fileFilter
+	}
+
+	// Discover files in the background so analysis starts before the walk ends.
+	paths := make(chan string, jobs)
+	go func() {
+		defer close(paths)
+		emit := func(path string) {
+			select {
+			case paths <- path:
+				p.discovered.Add(1)
+			case <-ctx.Done():
+			}
+		}
+		if cfg.Stdin != nil {
+			walk.WalkSeq(ctx, cfg.Stdin.All(), walkOpts, emit)
+			return
+		}
+		walk.Walk(ctx, cfg.Files, walkOpts, emit)
+	}()
+
+	results := make(chan analyze.FileStats, jobs)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				// Per-file errors are carried in the stats and shown as rows;
+				// files cut off by cancellation are dropped.
+				stat, err := work(ctx, path)
+				if err != nil && ctx.Err() != nil {
+					continue
+				}
+				results <- stat
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
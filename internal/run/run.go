@@ -1,24 +1,25 @@
 package run
 
 import (
-	"encoding/csv"
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
-	"regexp"
+	"os/signal"
 	"runtime"
-	"sort"
+	"slices"
 	"strings"
-	"sync"
-	"unicode/utf8"
+	"syscall"
 
+	"github.com/ADJB1212/Aperio/aperio"
 	"github.com/ADJB1212/Aperio/internal/analyze"
 	"github.com/ADJB1212/Aperio/internal/cli"
-	"github.com/ADJB1212/Aperio/internal/icons"
 	"github.com/ADJB1212/Aperio/internal/ui/progress"
-	"github.com/ADJB1212/Aperio/internal/util"
 )
 
+// exitInterrupted is returned when SIGINT/SIGTERM cut a run short
+// (128 + SIGINT, as shells report it).
+const exitInterrupted = 130
+
 // Run coordinates the full aperio flow based on CLI flags.
 // It returns a process exit code (0 = success).
 func Run(version string) int {
@@ -26,7 +27,7 @@ func Run(version string) int {
 	if err != nil {
 		// Differentiate invalid flag values from generic usage errors when possible.
 		msg := err.Error()
-		if strings.HasPrefix(msg, "Invalid --sort") || strings.HasPrefix(msg, "Invalid --format") {
+		if strings.HasPrefix(msg, "Invalid --") {
 			fmt.Fprintln(os.Stderr, msg)
 			return 2
 		}
@@ -39,7 +40,26 @@ func Run(version string) int {
 		return 0
 	}
 
-	files := cfg.Files
+	// Compile templates before any file is read.
+	var tmpl *fileTemplates
+	if cfg.Format == "template" {
+		if tmpl, err = parseTemplates(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --template value: %s\n", templateError(err))
+			return 2
+		}
+	}
+
+	// Stop discovering and reading files on SIGINT/SIGTERM, then report what
+	// was collected. A second signal terminates immediately.
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-sigCtx.Done()
+		stop()
+	}()
+	// Output failures (e.g. a closed pipe) also stop the run, but quietly.
+	ctx, cancel := context.WithCancel(sigCtx)
+	defer cancel()
 
 	// Concurrency limit
 	jobs := cfg.Jobs
@@ -47,367 +67,227 @@ func Run(version string) int {
 		jobs = runtime.NumCPU()
 	}
 
-	// Analyze files concurrently
-	results := make(chan analyze.FileStats, len(files))
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, jobs)
-
-	for _, path := range files {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(p string) {
-			defer func() { <-sem }()
-			analyze.AnalyzeFile(p, results, &wg)
-		}(path)
+	if cfg.Duplicates {
+		return runDuplicates(ctx, sigCtx, cfg, jobs)
 	}
 
-	// Close results when done
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	var p pipeline
+	analyzer := aperio.New(aperio.Options{Hash: cfg.Hash, MaxLineLength: cfg.MaxLineLength, CharMode: cfg.CharMode, Prose: cfg.Prose})
+	results := p.start(ctx, cfg, jobs, func(ctx context.Context, path string) (analyze.FileStats, error) {
+		st, err := analyzer.AnalyzePath(ctx, path)
+		return analyze.FileStats(st), err
+	})
 
-	// Collect with optional progress
-	stats := make([]analyze.FileStats, 0, len(files))
+	// Collect with optional progress. The total grows as the walk discovers
+	// files. In --stream mode rows are written instead of kept.
+	var stats []analyze.FileStats
+	var stream streamWriter
+	var sum totals
+	var streamErr error
+	if cfg.Stream {
+		stream = newStreamWriter(os.Stdout, cfg)
+	}
+	resolver := newPathResolver(cfg.PathStyle, cfg.RelativeTo)
 	processed := 0
 	var bar *progress.Bar
-	if cfg.Progress && len(files) > 0 {
+	if cfg.Progress {
 		bar = progress.New(os.Stderr, 40)
-		bar.Render(processed, len(files))
+		bar.Render(processed, int(p.discovered.Load()))
 	}
 	for fs := range results {
-		stats = append(stats, fs)
+		processed++
 		if bar != nil {
-			processed++
-			bar.Render(processed, len(files))
+			bar.Render(processed, int(p.discovered.Load()))
+		}
+		resolver.apply(&fs)
+		if cfg.Where != nil && !matchWhere(cfg.Where, fs) {
+			continue
+		}
+		if stream == nil {
+			stats = append(stats, fs)
+		} else if streamErr == nil {
+			sum.add(fs)
+			streamErr = stream.write(fs)
+			if streamErr == nil && len(results) == 0 {
+				streamErr = stream.flush()
+			}
+			if streamErr != nil {
+				cancel()
+			}
 		}
 	}
+	interrupted := sigCtx.Err() != nil
 	if bar != nil {
+		if interrupted {
+			bar.SetLabel("interrupted")
+			bar.Render(processed, int(p.discovered.Load()))
+		}
 		bar.Finish()
 	}
 
-	// Sort results
-	sortStats(stats, cfg.SortBy, cfg.Desc)
-
-	// Output
-	switch cfg.Format {
-	case "json":
-		if err := writeJSON(stats); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-			return 1
-		}
-		return 0
-	case "csv":
-		if err := writeCSV(stats, !cfg.NoHeader); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
-			return 1
-		}
-		return 0
-	default: // table
-		writeTable(stats, cfg.ShowSum, cfg.Plain, cfg.Commas, cfg.NoIcons)
-		return 0
+	if !interrupted && !stdinOK(cfg) {
+		return 1
 	}
-}
 
-func sortStats(stats []analyze.FileStats, sortBy string, desc bool) {
-	sortBy = strings.ToLower(sortBy)
-	sort.Slice(stats, func(i, j int) bool {
-		a, b := stats[i], stats[j]
-		// Errors at the end
-		if a.HasError && !b.HasError {
-			return false
-		}
-		if !a.HasError && b.HasError {
-			return true
+	var code int
+	if stream != nil {
+		if streamErr == nil && cfg.ShowSum {
+			streamErr = stream.summary(sum, interrupted)
 		}
-		// Group text before binary
-		if a.Kind != b.Kind {
-			if a.Kind == "binary" {
-				return false
-			}
-			if b.Kind == "binary" {
-				return true
-			}
+		if streamErr == nil {
+			streamErr = stream.flush()
 		}
-		var less bool
-		switch sortBy {
-		case "name":
-			less = strings.ToLower(a.Name) < strings.ToLower(b.Name)
-		case "ext":
-			less = strings.ToLower(a.Ext) < strings.ToLower(b.Ext)
-		case "size":
-			less = a.SizeBytes < b.SizeBytes
-		case "lines":
-			less = a.Lines < b.Lines
-		case "words":
-			less = a.Words < b.Words
-		case "chars":
-			less = a.Chars < b.Chars
-		case "modified":
-			// Prefer ModUnix if available (0 means unknown)
-			if a.ModUnix != 0 || b.ModUnix != 0 {
-				less = a.ModUnix < b.ModUnix
-			} else {
-				less = a.ModTime < b.ModTime
-			}
-		default:
-			less = strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		if streamErr != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", streamErr)
+			return 1
 		}
-		if desc {
-			return !less
+	} else {
+		code = writeOutput(stats, cfg, tmpl)
+	}
+	if interrupted {
+		fmt.Fprintf(os.Stderr, "aperio: interrupted; results are partial (%d of %d discovered files)\n", processed, p.discovered.Load())
+		if code == 0 {
+			code = exitInterrupted
 		}
-		return less
-	})
-}
-
-func writeJSON(stats []analyze.FileStats) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(stats)
+	}
+	return code
 }
 
-func writeCSV(stats []analyze.FileStats, header bool) error {
-	w := csv.NewWriter(os.Stdout)
-	if header {
-		_ = w.Write([]string{"File", "Ext", "Kind", "SizeBytes", "Size", "Lines", "Words", "Chars", "Modified", "Error"})
+// stdinOK reports problems reading paths from stdin on stderr.
+func stdinOK(cfg cli.Config) bool {
+	if cfg.Stdin == nil {
+		return true
 	}
-	for _, fs := range stats {
-		if fs.HasError {
-			_ = w.Write([]string{fs.Name, fs.Ext, "", "", "", "", "", "", fs.ModTime, fs.ErrorText})
-			continue
-		}
-		ls := fmt.Sprintf("%d", fs.Lines)
-		ws := fmt.Sprintf("%d", fs.Words)
-		cs := fmt.Sprintf("%d", fs.Chars)
-		if fs.Kind == "binary" {
-			ls, ws, cs = "-", "-", "-"
-		}
-		_ = w.Write([]string{
-			fs.Name,
-			fs.Ext,
-			fs.Kind,
-			fmt.Sprintf("%d", fs.SizeBytes),
-			fs.Size,
-			ls,
-			ws,
-			cs,
-			fs.ModTime,
-			"",
-		})
+	if err := cfg.Stdin.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading paths from stdin: %v\n", err)
+		return false
 	}
-	w.Flush()
-	return w.Error()
+	if cfg.Stdin.Count() == 0 {
+		fmt.Fprintln(os.Stderr, "No file paths provided via stdin")
+		return false
+	}
+	return true
 }
 
-func writeTable(stats []analyze.FileStats, showSum bool, plain bool, commas bool, noIcons bool) {
-	// Headers: include Kind
-	headers := []string{"File", "Ext", "Kind", "Size", "Lines", "Words", "Chars", "Modified"}
-
-	// helpers
-	reANSI := regexp.MustCompile("\x1b\\[[0-9;]*m")
-	stripANSI := func(s string) string { return reANSI.ReplaceAllString(s, "") }
-	displayWidth := func(s string) int {
-		return utf8.RuneCountInString(stripANSI(s))
-	}
-	padRight := func(s string, width int) string {
-		pad := width - displayWidth(s)
-		if pad > 0 {
-			return s + strings.Repeat(" ", pad)
-		}
-		return s
+// writeOutput renders collected stats in the configured format and returns
+// the process exit code.
+func writeOutput(stats []analyze.FileStats, cfg cli.Config, tmpl *fileTemplates) int {
+	if cfg.Checking() {
+		return writeChecks(stats, cfg)
 	}
-	padLeft := func(s string, width int) string {
-		pad := width - displayWidth(s)
-		if pad > 0 {
-			return strings.Repeat(" ", pad) + s
-		}
-		return s
+	if len(cfg.GroupBy) > 0 {
+		return writeGroups(stats, cfg)
 	}
-	fmtInt := func(n int) string {
-		if commas {
-			return util.CommaInt(n)
+
+	// Sort results, totaling before --top folds the tail into one row.
+	newSorter(cfg).sortStats(stats)
+	var sum *totals
+	if cfg.ShowSum {
+		sum = new(totals)
+		for _, fs := range stats {
+			sum.add(fs)
 		}
-		return fmt.Sprintf("%d", n)
 	}
-	// columns 3..6 right-aligned: Size, Lines, Words, Chars (0-based index)
-	rightAligned := map[int]bool{3: true, 4: true, 5: true, 6: true}
-
-	var rows [][]string
-	var totalBytes int64
-	var totalLines, totalWords, totalChars int
+	all := stats
+	stats = topStats(stats, cfg.Top)
 
-	for _, fs := range stats {
-		// Compose extension display with optional colored Nerd Fonts icon.
-		extDisplay := fs.Ext
-		if !noIcons {
-			if ic := icons.Icon(fs.Name); ic != "" {
-				// Colorize icon based on extension (best-effort).
-				colorCode := func(ext string) int {
-					switch strings.ToLower(ext) {
-					case ".go":
-						return 51
-					case ".rs":
-						return 202
-					case ".js", ".jsx", ".mjs", ".cjs":
-						return 184
-					case ".ts", ".tsx":
-						return 27
-					case ".py":
-						return 220
-					case ".c", ".h", ".hpp", ".hh", ".hxx", ".cc", ".cpp", ".cxx":
-						return 27
-					case ".java", ".scala", ".swift", ".rb":
-						return 196
-					case ".kt", ".kts", ".php", ".hs":
-						return 129
-					case ".lua":
-						return 33
-					case ".html":
-						return 160
-					case ".cs", ".csx":
-						return 55
-					case ".css", ".scss", ".sass", ".less":
-						return 162
-					case ".json", ".yaml", ".yml", ".toml", ".ini":
-						return 242
-					case ".sh", ".bash", ".zsh", ".ksh", ".fish", ".vim":
-						return 41
-					case ".zig":
-						return 208
-					default:
-						return 244
-					}
-				}(fs.Ext)
-				coloredIcon := util.Colorize(ic, colorCode, -1)
-				extDisplay = fs.Ext + " " + coloredIcon
-			}
+	// Output
+	cols := outputColumns(cfg)
+	opts := cellOpts{commas: cfg.Commas, icons: !cfg.NoIcons, pathStyle: cfg.PathStyle}
+	switch cfg.Format {
+	case "json":
+		if err := writeJSON(stats, cols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			return 1
 		}
-
-		if fs.HasError {
-			rows = append(rows, []string{fs.Name, extDisplay, "-", "-", "-", "-", "-", fs.ErrorText})
-			continue
+		return 0
+	case "csv":
+		if err := writeCSV(stats, cols, !cfg.NoHeader, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
 		}
-		lstr, wstr, cstr := fmtInt(fs.Lines), fmtInt(fs.Words), fmtInt(fs.Chars)
-		if fs.Kind == "binary" {
-			lstr, wstr, cstr = "-", "-", "-"
+		return 0
+	case "template":
+		// The row template sees only real files: --top drops the tail
+		// instead of folding it into an "others" row.
+		rows := all
+		if cfg.Top > 0 {
+			rows = all[:min(cfg.Top, len(all))]
 		}
-		rows = append(rows, []string{
-			fs.Name,
-			extDisplay,
-			fs.Kind,
-			fs.Size,
-			lstr,
-			wstr,
-			cstr,
-			fs.ModTime,
-		})
-		totalBytes += fs.SizeBytes
-		if fs.Kind != "binary" {
-			totalLines += fs.Lines
-			totalWords += fs.Words
-			totalChars += fs.Chars
+		if err := writeTemplate(os.Stdout, tmpl, rows, all); err != nil {
+			fmt.Fprintf(os.Stderr, "Error executing template: %s\n", templateError(err))
+			return 1
 		}
-	}
-
-	// compute column widths
-	widths := make([]int, len(headers))
-	for i, h := range headers {
-		widths[i] = displayWidth(h)
-	}
-	for _, row := range rows {
-		for i, cell := range row {
-			if w := displayWidth(cell); w > widths[i] {
-				widths[i] = w
-			}
+		return 0
+	case "markdown":
+		// Icons need a Nerd Font and color codes a terminal.
+		opts.icons = false
+		writeMarkdown(os.Stdout, buildTable(stats, cols, sum, opts))
+		return 0
+	case "html":
+		opts.icons = false
+		if err := writeHTML(os.Stdout, buildTable(stats, cols, sum, opts), all); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing HTML: %v\n", err)
+			return 1
 		}
+		return 0
+	default: // table
+		writeTable(stats, cols, sum, cfg.Plain, opts)
+		return 0
 	}
+}
 
-	// optional footer
-	var hasFooter bool
-	var footer []string
-	if showSum {
-		hasFooter = true
-		footer = []string{
-			fmt.Sprintf("TOTAL (%d files)", len(stats)),
-			"",
-			"",
-			analyze.HumanBytes(totalBytes),
-			fmtInt(totalLines),
-			fmtInt(totalWords),
-			fmtInt(totalChars),
-			"",
+// outputColumns returns the per-file columns for cfg: the --columns
+// selection, or the format's defaults (plus the prose metrics with --prose
+// and Hash with --hash). It is nil for
+// JSON without --columns, which writes whole FileStats records.
+func outputColumns(cfg cli.Config) []column {
+	keys := cfg.Columns
+	if keys == nil {
+		switch cfg.Format {
+		case "json", "ndjson":
+			return nil
+		case "csv":
+			keys = csvColumns
+		default:
+			keys = tableColumns
 		}
-		for i, cell := range footer {
-			if w := displayWidth(cell); w > widths[i] {
-				widths[i] = w
-			}
+		if cfg.Prose {
+			keys = append(slices.Clip(keys), proseColumns...)
+		}
+		if cfg.Hash != "" {
+			keys = append(slices.Clip(keys), "hash")
 		}
 	}
+	return selectColumns(keys)
+}
 
-	// drawing characters
-	vert := "│"
-	horiz := "─"
-	topLeft, topSep, topRight := "╭", "┬", "╮"
-	midLeft, midSep, midRight := "├", "┼", "┤"
-	botLeft, botSep, botRight := "╰", "┴", "╯"
-	if plain {
-		vert = "|"
-		horiz = "-"
-		topLeft, topSep, topRight = "+", "+", "+"
-		midLeft, midSep, midRight = "+", "+", "+"
-		botLeft, botSep, botRight = "+", "+", "+"
-	}
+// writeGroups renders a --group-by summary instead of per-file rows.
+func writeGroups(stats []analyze.FileStats, cfg cli.Config) int {
+	groups := groupStats(stats, cfg.GroupBy)
+	sortGroups(groups, newSorter(cfg))
+	groups = topGroups(groups, cfg.Top)
 
-	// draw line helpers
-	drawLine := func(left, sep, right string) {
-		var b strings.Builder
-		b.WriteString(left)
-		for i, w := range widths {
-			b.WriteString(strings.Repeat(horiz, w+2))
-			if i < len(widths)-1 {
-				b.WriteString(sep)
-			}
-		}
-		b.WriteString(right)
-		fmt.Fprintln(os.Stdout, b.String())
-	}
-	// draw row helper
-	drawRow := func(cells []string) {
-		var b strings.Builder
-		b.WriteString(vert)
-		for i, w := range widths {
-			cell := ""
-			if i < len(cells) {
-				cell = cells[i]
-			}
-			if rightAligned[i] {
-				b.WriteString(" " + padLeft(cell, w) + " ")
-			} else {
-				b.WriteString(" " + padRight(cell, w) + " ")
-			}
-			if i < len(widths)-1 {
-				b.WriteString(vert)
-			}
+	switch cfg.Format {
+	case "json":
+		if err := writeGroupJSON(groups, cfg.GroupBy); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			return 1
 		}
-		b.WriteString(vert)
-		fmt.Fprintln(os.Stdout, b.String())
-	}
-
-	// render table
-	drawLine(topLeft, topSep, topRight)
-	drawRow(headers)
-	drawLine(midLeft, midSep, midRight)
-	for i, row := range rows {
-		drawRow(row)
-		if i < len(rows)-1 {
-			drawLine(midLeft, midSep, midRight)
+	case "csv":
+		if err := writeGroupCSV(groups, cfg.GroupBy, !cfg.NoHeader); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
 		}
-	}
-	if hasFooter {
-		if len(rows) > 0 {
-			drawLine(midLeft, midSep, midRight)
+	case "markdown":
+		writeMarkdown(os.Stdout, buildGroupTable(groups, cfg.GroupBy, cfg.ShowSum, cfg.Commas))
+	case "html":
+		if err := writeHTML(os.Stdout, buildGroupTable(groups, cfg.GroupBy, cfg.ShowSum, cfg.Commas), stats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing HTML: %v\n", err)
+			return 1
 		}
-		drawRow(footer)
+	default: // table
+		writeGroupTable(groups, cfg.GroupBy, cfg.ShowSum, cfg.Plain, cfg.Commas)
 	}
-	drawLine(botLeft, botSep, botRight)
+	return 0
 }
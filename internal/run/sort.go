@@ -0,0 +1,147 @@
+package run
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/cli"
+)
+
+// sorter orders stats by a list of --sort keys.
+type sorter struct {
+	keys []cli.SortKey
+	// natural compares digit runs in names by value, so file2 < file10.
+	natural bool
+	// grouped keeps text before binary files and errors last, whatever the keys.
+	grouped bool
+}
+
+func newSorter(cfg cli.Config) sorter {
+	return sorter{keys: cfg.Sort, natural: cfg.Natural, grouped: !cfg.Ungrouped}
+}
+
+// sortStats sorts stats in place. The sort is stable and falls back to the
+// path, so equal keys come out in the same order on every run.
+func (s sorter) sortStats(stats []analyze.FileStats) {
+	slices.SortStableFunc(stats, func(a, b analyze.FileStats) int {
+		if s.grouped {
+			// Errors at the end, then text before binary
+			if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+				return c
+			}
+		}
+		for _, k := range s.keys {
+			c := s.compare(k.Key, a, b)
+			if k.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return s.text(a.Path, b.Path)
+	})
+}
+
+func rank(fs analyze.FileStats) int {
+	switch {
+	case fs.HasError:
+		return 2
+	case fs.Kind == "binary":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (s sorter) compare(key string, a, b analyze.FileStats) int {
+	switch key {
+	case "name":
+		return s.text(a.Name, b.Name)
+	case "path":
+		return s.text(a.Path, b.Path)
+	case "ext":
+		return s.text(a.Ext, b.Ext)
+	case "language":
+		return s.text(a.Language, b.Language)
+	case "kind":
+		return cmp.Compare(a.Kind, b.Kind)
+	case "mime":
+		return s.text(a.MIME, b.MIME)
+	case "type":
+		return s.text(a.Type, b.Type)
+	case "encoding":
+		return s.text(a.Encoding, b.Encoding)
+	case "size":
+		return cmp.Compare(a.SizeBytes, b.SizeBytes)
+	case "lines":
+		return cmp.Compare(a.Lines, b.Lines)
+	case "code":
+		return cmp.Compare(a.CodeLines, b.CodeLines)
+	case "comment":
+		return cmp.Compare(a.CommentLines, b.CommentLines)
+	case "blank":
+		return cmp.Compare(a.BlankLines, b.BlankLines)
+	case "words":
+		return cmp.Compare(a.Words, b.Words)
+	case "chars":
+		return cmp.Compare(a.Chars, b.Chars)
+	case "modified":
+		// Prefer ModUnix if available (0 means unknown)
+		if a.ModUnix != 0 || b.ModUnix != 0 {
+			return cmp.Compare(a.ModUnix, b.ModUnix)
+		}
+		return cmp.Compare(a.ModTime, b.ModTime)
+	}
+	return 0
+}
+
+// text compares strings case-insensitively, in natural order if enabled.
+func (s sorter) text(a, b string) int {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if s.natural {
+		return naturalCompare(a, b)
+	}
+	return strings.Compare(a, b)
+}
+
+// naturalCompare orders runs of ASCII digits by their numeric value and
+// everything else byte by byte: "file2" < "file10" < "file010b".
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitRun(a), digitRun(b)
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			// Longer runs (without leading zeros) are larger numbers.
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			// Equal values: fewer leading zeros first.
+			if c := cmp.Compare(len(da), len(db)); c != 0 {
+				return c
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func digitRun(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
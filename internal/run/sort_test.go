@@ -0,0 +1,34 @@
+package run
+
+import "testing"
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"a", "a", 0},
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file10", "file10", 0},
+		{"file10", "file010b", -1},
+		{"file010", "file10", 1},
+		{"file007", "file7", 1},
+		{"file0", "file00", -1},
+		{"a1b2", "a1b10", -1},
+		{"a10b1", "a9b2", 1},
+		{"v1.2.10", "v1.2.9", 1},
+		{"99999999999999999999999", "100000000000000000000000", -1},
+		{"file", "file1", -1},
+		{"file1", "file", 1},
+		{"1", "a", -1},
+		{"B", "a", -1},
+		{"x.go", "x_test.go", -1},
+	}
+	for _, tt := range tests {
+		if got := naturalCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
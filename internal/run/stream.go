@@ -0,0 +1,135 @@
+package run
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/cli"
+)
+
+// streamWriter writes --stream rows as results arrive, without holding them.
+type streamWriter interface {
+	write(fs analyze.FileStats) error
+	// summary reports the totals of the run for --sum, before the last flush.
+	summary(sum totals, partial bool) error
+	// flush pushes buffered rows out. It is called whenever the writer has
+	// caught up with the workers and once more at the end.
+	flush() error
+}
+
+func newStreamWriter(out io.Writer, cfg cli.Config) streamWriter {
+	cols := outputColumns(cfg)
+	if cfg.Format == "csv" {
+		opts := cellOpts{pathStyle: cfg.PathStyle}
+		return &csvStream{w: csv.NewWriter(out), cols: cols, header: !cfg.NoHeader, opts: opts, commas: cfg.Commas}
+	}
+	buf := bufio.NewWriter(out)
+	return &ndjsonStream{buf: buf, enc: json.NewEncoder(buf), cols: cols}
+}
+
+// csvStream writes the same rows as writeCSV, in completion order. The
+// header is deferred to the first row so a failed run prints nothing.
+type csvStream struct {
+	w      *csv.Writer
+	cols   []column
+	header bool
+	opts   cellOpts
+	commas bool
+}
+
+func (s *csvStream) write(fs analyze.FileStats) error {
+	if s.header {
+		s.header = false
+		if err := s.w.Write(headers(s.cols)); err != nil {
+			return err
+		}
+	}
+	return s.w.Write(csvRecord(fs, s.cols, s.opts))
+}
+
+// summary goes to stderr so the CSV on stdout stays rectangular.
+func (s *csvStream) summary(sum totals, partial bool) error {
+	writeStreamTotals(os.Stderr, sum, s.commas)
+	return nil
+}
+
+func (s *csvStream) flush() error {
+	if s.header {
+		s.header = false
+		_ = s.w.Write(headers(s.cols))
+	}
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// ndjsonStream writes one compact JSON object per line. Each record has a
+// "record" of "file", and the optional --sum record comes last with
+// "summary". The key is not "type", which would collide with FileStats.Type
+// for decoders that match names case-insensitively.
+type ndjsonStream struct {
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	cols []column
+}
+
+// ndjsonFile is a FileStats record tagged with its record kind.
+type ndjsonFile struct {
+	Record string `json:"record"`
+	analyze.FileStats
+}
+
+// ndjsonSummary is the final --sum record of an ndjson stream.
+type ndjsonSummary struct {
+	Record       string `json:"record"`
+	Files        int
+	TextFiles    int
+	SizeBytes    int64
+	Size         string
+	Lines        int
+	CodeLines    int
+	CommentLines int
+	BlankLines   int
+	Words        int
+	Chars        int
+	// Partial is set when the run was interrupted before every file was read.
+	Partial bool `json:",omitempty"`
+}
+
+func (s *ndjsonStream) write(fs analyze.FileStats) error {
+	if s.cols != nil {
+		return s.enc.Encode(columnRecord{record: "file", fs: fs, cols: s.cols})
+	}
+	return s.enc.Encode(ndjsonFile{Record: "file", FileStats: fs})
+}
+
+func (s *ndjsonStream) summary(sum totals, partial bool) error {
+	return s.enc.Encode(ndjsonSummary{
+		Record:       "summary",
+		Files:        sum.Files,
+		TextFiles:    sum.TextFiles,
+		SizeBytes:    sum.Bytes,
+		Size:         analyze.HumanBytes(sum.Bytes),
+		Lines:        sum.Lines,
+		CodeLines:    sum.Code,
+		CommentLines: sum.Comment,
+		BlankLines:   sum.Blank,
+		Words:        sum.Words,
+		Chars:        sum.Chars,
+		Partial:      partial,
+	})
+}
+
+func (s *ndjsonStream) flush() error { return s.buf.Flush() }
+
+// writeStreamTotals prints the --sum totals of a streamed run as one line.
+func writeStreamTotals(out io.Writer, sum totals, commas bool) {
+	fmtInt := func(n int) string { return formatInt(n, commas) }
+	fmt.Fprintf(out, "TOTAL (%s files): %s, %s lines, %s code, %s comment, %s blank, %s words, %s chars\n",
+		fmtInt(sum.Files), analyze.HumanBytes(sum.Bytes), fmtInt(sum.Lines), fmtInt(sum.Code),
+		fmtInt(sum.Comment), fmtInt(sum.Blank), fmtInt(sum.Words), fmtInt(sum.Chars))
+}
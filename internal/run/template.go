@@ -0,0 +1,102 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+	"github.com/ADJB1212/Aperio/internal/cli"
+	"github.com/ADJB1212/Aperio/internal/util"
+)
+
+// templateFuncs are the helpers available to --template.
+var templateFuncs = template.FuncMap{
+	"humanBytes": func(n any) (string, error) {
+		v, err := toInt64(n)
+		return util.HumanBytes(v), err
+	},
+	"commas": func(n any) (string, error) {
+		v, err := toInt64(n)
+		return util.CommaInt64(v), err
+	},
+	// pad fills s with spaces to width; a negative width pads on the left.
+	"pad": func(width int, s any) string {
+		str := fmt.Sprint(s)
+		if width < 0 {
+			return padLeft(str, -width)
+		}
+		return padRight(str, width)
+	},
+	// color wraps s in a 256-color foreground escape.
+	"color": func(code int, s any) string {
+		return util.Colorize(fmt.Sprint(s), code, -1)
+	},
+}
+
+func toInt64(n any) (int64, error) {
+	switch v := n.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", n)
+	}
+}
+
+// fileTemplates holds the compiled --template, --template-header and
+// --template-footer. A template file may also define "header" and "footer".
+type fileTemplates struct {
+	row, header, footer *template.Template
+}
+
+func parseTemplates(cfg cli.Config) (*fileTemplates, error) {
+	row, err := template.New("row").Funcs(templateFuncs).Parse(cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	t := &fileTemplates{row: row, header: row.Lookup("header"), footer: row.Lookup("footer")}
+	if cfg.TemplateHeader != "" {
+		if t.header, err = template.New("header").Funcs(templateFuncs).Parse(cfg.TemplateHeader); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.TemplateFooter != "" {
+		if t.footer, err = template.New("footer").Funcs(templateFuncs).Parse(cfg.TemplateFooter); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// writeTemplate runs the row template for each of rows, between the header
+// and footer, which receive the totals over all files.
+func writeTemplate(out io.Writer, t *fileTemplates, rows, all []analyze.FileStats) error {
+	var sum totals
+	for _, fs := range all {
+		sum.add(fs)
+	}
+	if t.header != nil {
+		if err := t.header.Execute(out, sum); err != nil {
+			return err
+		}
+	}
+	for _, fs := range rows {
+		if err := t.row.Execute(out, fs); err != nil {
+			return err
+		}
+	}
+	if t.footer != nil {
+		return t.footer.Execute(out, sum)
+	}
+	return nil
+}
+
+// templateError trims text/template's "template: row:" prefix for messages.
+func templateError(err error) string {
+	return strings.TrimPrefix(err.Error(), "template: ")
+}
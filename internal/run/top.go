@@ -0,0 +1,52 @@
+package run
+
+import (
+	"fmt"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+)
+
+// othersKind marks the row --top folds the remaining files into.
+const othersKind = "others"
+
+// topStats keeps the first n sorted stats and folds the rest into one
+// "N others" row holding their totals. n <= 0 keeps everything.
+func topStats(stats []analyze.FileStats, n int) []analyze.FileStats {
+	if n <= 0 || len(stats) <= n {
+		return stats
+	}
+	var t totals
+	for _, fs := range stats[n:] {
+		t.add(fs)
+	}
+	label := fmt.Sprintf("%d others", t.Files)
+	others := analyze.FileStats{
+		Name:         label,
+		Path:         label,
+		Kind:         othersKind,
+		SizeBytes:    t.Bytes,
+		Size:         analyze.HumanBytes(t.Bytes),
+		Lines:        t.Lines,
+		CodeLines:    t.Code,
+		CommentLines: t.Comment,
+		BlankLines:   t.Blank,
+		Words:        t.Words,
+		Chars:        t.Chars,
+	}
+	return append(stats[:n:n], others)
+}
+
+// topGroups keeps the first n sorted groups and merges the rest into one
+// "N others" group. n <= 0 keeps everything.
+func topGroups(groups []group, n int) []group {
+	if n <= 0 || len(groups) <= n {
+		return groups
+	}
+	rest := groups[n:]
+	others := group{Keys: make([]string, len(rest[0].Keys)), folded: len(rest)}
+	others.Keys[0] = fmt.Sprintf("%d others", len(rest))
+	for _, g := range rest {
+		others.merge(g.totals)
+	}
+	return append(groups[:n:n], others)
+}
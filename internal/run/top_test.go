@@ -0,0 +1,43 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/ADJB1212/Aperio/internal/analyze"
+)
+
+func TestTopStatsOthersRow(t *testing.T) {
+	stats := []analyze.FileStats{
+		{Name: "a.go", Kind: "text", Language: "Go", SizeBytes: 300, Lines: 30, CodeLines: 20, Words: 90, MaxLineLength: 80, LF: 30},
+		{Name: "b.go", Kind: "text", Language: "Go", SizeBytes: 200, Lines: 20, CodeLines: 15, Words: 60, MaxLineLength: 100, LF: 20},
+		{Name: "c.txt", Kind: "text", SizeBytes: 100, Lines: 10, Words: 40, MaxLineLength: 60, LF: 10},
+	}
+	rows := topStats(stats, 1)
+	if len(rows) != 2 {
+		t.Fatalf("topStats kept %d rows, want 2", len(rows))
+	}
+	others := rows[1]
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"name", "2 others"},
+		{"size", "300 B"},
+		{"lines", "30"},
+		{"code", "15"},
+		{"words", "100"},
+		// Values that do not add up across files are left out.
+		{"lf", "-"},
+		{"line_max", "-"},
+		{"line_mean", "-"},
+		{"indent_max", "-"},
+		{"final_newline", "-"},
+		{"sentences", "-"},
+	}
+	for _, tt := range tests {
+		if got := columnIndex[tt.key].cell(others, cellOpts{}); got != tt.want {
+			t.Errorf("others %s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
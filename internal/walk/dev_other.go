@@ -0,0 +1,10 @@
+//go:build !unix
+
+package walk
+
+import "io/fs"
+
+// deviceID is unsupported on this platform; --one-file-system is a no-op.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
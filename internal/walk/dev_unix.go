@@ -0,0 +1,17 @@
+//go:build unix
+
+package walk
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// deviceID returns the device number backing info, if the platform exposes it.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
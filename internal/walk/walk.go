@@ -0,0 +1,272 @@
+package walk
+
+import (
+	"context"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ADJB1212/Aperio/internal/ignore"
+)
+
+// Options controls how directory arguments are expanded into files.
+type Options struct {
+	// Recursive descends into subdirectories. Without it, a directory
+	// argument expands to the files directly inside it.
+	Recursive bool
+	// MaxDepth limits how deep a recursive walk goes below each root
+	// (1 = direct children only). 0 means unlimited.
+	MaxDepth int
+	// FollowSymlinks descends into symlinked directories found during the walk.
+	FollowSymlinks bool
+	// OneFileSystem stops at directories that live on a different device
+	// than the root they were reached from.
+	OneFileSystem bool
+	// Ignore honors .gitignore, .ignore and .aperioignore files found in the
+	// walked directories and skips .git directories.
+	Ignore bool
+	// Hidden includes dotfiles and dot-directories found during the walk.
+	Hidden bool
+	// Filter, if set, prunes directories and drops files before they are emitted.
+	Filter Filter
+}
+
+// Filter decides which entries a walk keeps. rel is an entry's path relative
+// to the walk root it was found under, or the path as given for file arguments.
+type Filter interface {
+	KeepDir(rel string) bool
+	KeepFile(path, rel string, d fs.DirEntry) bool
+}
+
+type walker struct {
+	ctx  context.Context
+	opts Options
+	emit func(path string)
+	root string
+	// visited holds the resolved absolute path of every directory walked,
+	// so a directory reached again through a symlink, or named twice, is
+	// counted once.
+	visited map[string]struct{}
+	// matchers maps each walked directory (as reported) to the ignore rules in
+	// effect inside it. Entries are loaded as the walk enters a directory.
+	matchers map[string]*ignore.Matcher
+}
+
+// Walk expands roots into file paths and calls emit for each file as it is
+// discovered. Non-directory roots are emitted unchanged, including paths that
+// cannot be stat'ed, so the analyzer can report them as error rows. The walk
+// stops early once ctx is canceled.
+func Walk(ctx context.Context, roots []string, opts Options, emit func(path string)) {
+	WalkSeq(ctx, slices.Values(roots), opts, emit)
+}
+
+// WalkSeq is like Walk but pulls roots from a sequence as it goes, so a long
+// stream of paths never has to be held in memory.
+func WalkSeq(ctx context.Context, roots iter.Seq[string], opts Options, emit func(path string)) {
+	w := &walker{
+		ctx:      ctx,
+		opts:     opts,
+		emit:     emit,
+		visited:  make(map[string]struct{}),
+		matchers: make(map[string]*ignore.Matcher),
+	}
+	for root := range roots {
+		if ctx.Err() != nil {
+			return
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			emit(root)
+			continue
+		}
+		if !info.IsDir() {
+			if opts.Filter == nil || opts.Filter.KeepFile(root, filepath.Clean(root), fs.FileInfoToDirEntry(info)) {
+				emit(root)
+			}
+			continue
+		}
+		dev, _ := deviceID(info)
+		w.root = filepath.Clean(root)
+		var parent *ignore.Matcher
+		if opts.Ignore {
+			// Rules from above the root still apply, as they do for git.
+			parent = ignore.LoadAncestors(resolve(root), w.root)
+		}
+		w.walkDir(w.root, root, 0, dev, parent)
+	}
+}
+
+func (w *walker) maxDepth() int {
+	if !w.opts.Recursive {
+		return 1
+	}
+	return w.opts.MaxDepth
+}
+
+// walkDir walks dir, reporting paths under display (which differs from dir
+// when dir was reached through a symlink). base is the depth of display
+// relative to its walk root, and parent the ignore rules inherited from above.
+func (w *walker) walkDir(display, dir string, base int, rootDev uint64, parent *ignore.Matcher) {
+	real := resolve(dir)
+	if _, seen := w.visited[real]; seen {
+		return
+	}
+	w.visited[real] = struct{}{}
+
+	// Walk the resolved directory so symlinked roots are entered too, and
+	// map each path back under the name the user knows it by.
+	maxDepth := w.maxDepth()
+	_ = filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+		if w.ctx.Err() != nil {
+			return fs.SkipAll
+		}
+		shown := display
+		if path != real {
+			shown = filepath.Join(display, strings.TrimPrefix(path, real))
+		}
+		if err != nil {
+			// Unreadable entries, the walked directory itself included,
+			// surface as error rows from the analyzer.
+			w.emit(shown)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		depth := base
+		if path != real {
+			rel, _ := filepath.Rel(real, path)
+			depth += strings.Count(rel, string(filepath.Separator)) + 1
+		}
+
+		if path == real {
+			w.enter(real, shown, parent)
+			return nil
+		}
+		if w.skip(shown, d) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.IsDir():
+			if maxDepth > 0 && depth >= maxDepth {
+				return fs.SkipDir
+			}
+			if w.opts.OneFileSystem && !w.sameDevice(d, rootDev) {
+				return fs.SkipDir
+			}
+			if w.opts.Filter != nil && !w.opts.Filter.KeepDir(w.rel(shown)) {
+				return fs.SkipDir
+			}
+			// WalkDir does not follow links, so path is already resolved.
+			// A followed symlink may have reached it first.
+			if _, seen := w.visited[path]; seen {
+				return fs.SkipDir
+			}
+			w.visited[path] = struct{}{}
+			w.enter(path, shown, w.matchers[filepath.Dir(shown)])
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Stat(path)
+			if err != nil || !target.IsDir() {
+				// Symlinked files are analyzed through the link; broken links
+				// are reported as errors.
+				w.emitFile(shown, d)
+				return nil
+			}
+			if !w.opts.FollowSymlinks || (maxDepth > 0 && depth >= maxDepth) {
+				return nil
+			}
+			if w.opts.Filter != nil && !w.opts.Filter.KeepDir(w.rel(shown)) {
+				return nil
+			}
+			if w.opts.OneFileSystem {
+				if dev, ok := deviceID(target); ok && dev != rootDev {
+					return nil
+				}
+			}
+			w.walkDir(shown, path, depth, rootDev, w.matchers[filepath.Dir(shown)])
+			return nil
+		case d.Type().IsRegular():
+			w.emitFile(shown, d)
+			return nil
+		default:
+			// Skip sockets, devices and named pipes found while walking.
+			return nil
+		}
+	})
+}
+
+// resolve returns the absolute path of dir with symlinks evaluated, or as
+// much of that as can be worked out.
+func resolve(dir string) string {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		real = dir
+	}
+	if abs, err := filepath.Abs(real); err == nil {
+		real = abs
+	}
+	return real
+}
+
+// emitFile emits a discovered file unless the filter drops it.
+func (w *walker) emitFile(shown string, d fs.DirEntry) {
+	if w.opts.Filter == nil || w.opts.Filter.KeepFile(shown, w.rel(shown), d) {
+		w.emit(shown)
+	}
+}
+
+// rel returns shown relative to the current walk root.
+func (w *walker) rel(shown string) string {
+	if rel, err := filepath.Rel(w.root, shown); err == nil {
+		return rel
+	}
+	return shown
+}
+
+// enter records the ignore rules in effect inside a directory the walk is
+// about to descend into.
+func (w *walker) enter(real, shown string, parent *ignore.Matcher) {
+	if w.opts.Ignore {
+		parent = ignore.Load(real, shown, parent)
+	}
+	w.matchers[shown] = parent
+}
+
+// skip reports whether an entry found during the walk is hidden or ignored.
+func (w *walker) skip(shown string, d fs.DirEntry) bool {
+	name := d.Name()
+	if !w.opts.Hidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	if !w.opts.Ignore {
+		return false
+	}
+	if d.IsDir() && name == ".git" {
+		return true
+	}
+	isDir := d.IsDir()
+	if d.Type()&fs.ModeSymlink != 0 {
+		if info, err := os.Stat(shown); err == nil {
+			isDir = info.IsDir()
+		}
+	}
+	return w.matchers[filepath.Dir(shown)].Match(shown, isDir)
+}
+
+func (w *walker) sameDevice(d fs.DirEntry, rootDev uint64) bool {
+	info, err := d.Info()
+	if err != nil {
+		return true
+	}
+	dev, ok := deviceID(info)
+	return !ok || dev == rootDev
+}
@@ -0,0 +1,62 @@
+package width
+
+import "testing"
+
+func TestRune(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{' ', 1},
+		{'\t', 0},
+		{'\n', 0},
+		{0x7f, 0},
+		{0x85, 0},
+		{'\u00e9', 1},
+		{'\u0301', 0}, // combining acute accent
+		{'\u200d', 0}, // zero width joiner
+		{'\ufe0f', 0}, // variation selector
+		{'\u1160', 0}, // Hangul medial vowel
+		{'日', 2},
+		{'한', 2},
+		{'Ａ', 2}, // fullwidth A
+		{'ｱ', 1}, // halfwidth katakana
+		{'😀', 2},
+		{'⌚', 2},
+		{'☺', 1},
+		{0x20000, 2},
+	}
+	for _, tt := range tests {
+		if got := Rune(tt.r); got != tt.want {
+			t.Errorf("Rune(%U) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"日本語", 6},
+		{"mixed 日本", 10},
+		{"e\u0301", 1},
+		{"a\tb", 2},
+		{"😀!", 3},
+		// A grapheme cluster takes the width of its widest rune.
+		{"\u1100\u1161\u11a8", 2},
+		{"\U0001f44d\U0001f3fd", 2},
+		{"\U0001f468\u200d\U0001f469\u200d\U0001f467", 2},
+		{"\U0001f1e9\U0001f1ea\U0001f1eb\U0001f1f7", 4},
+		{"\u2764", 1},
+		{"\u2764\ufe0f", 2},
+	}
+	for _, tt := range tests {
+		if got := String(tt.s); got != tt.want {
+			t.Errorf("String(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}